@@ -13,15 +13,21 @@ import (
 
 	conf "github.com/AnatolyGolang/book-shop/config"
 	"github.com/AnatolyGolang/book-shop/internal/app/http/handlers"
+	"github.com/AnatolyGolang/book-shop/internal/app/http/ratelimit"
 	"github.com/AnatolyGolang/book-shop/internal/app/logger"
 	"github.com/AnatolyGolang/book-shop/internal/app/repositories"
 	"github.com/AnatolyGolang/book-shop/internal/app/services"
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
+	"github.com/AnatolyGolang/book-shop/internal/pkg/mailer"
+	"github.com/AnatolyGolang/book-shop/internal/pkg/password"
 	"github.com/AnatolyGolang/book-shop/internal/pkg/postgres"
+	"github.com/AnatolyGolang/book-shop/internal/pkg/secretbox"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func main() {
@@ -58,40 +64,150 @@ func run() error {
 	}
 
 	bookRepository := repositories.NewBookRepository(dbCon)
-	bookService := services.NewBookService(bookRepository)
+	stockMovementRepository := repositories.NewStockMovementRepository(dbCon)
+	bookService := services.NewBookService(bookRepository, stockMovementRepository)
 
 	categoryRepository := repositories.NewCategoryRepository(dbCon)
 	categoryService := services.NewCategoryService(categoryRepository)
 
 	userRepository := repositories.NewUserRepository(dbCon)
-	userService := services.NewUserService(userRepository)
+	auditRepository := repositories.NewAuditRepository(dbCon)
+	loginThrottleRepository := repositories.NewLoginThrottleRepository(dbCon)
+	passwordHasher, err := newPasswordHasher(config.PasswordHash)
+	if err != nil {
+		return fmt.Errorf("run: error create password hasher %w", err)
+	}
 
-	cartRepository := repositories.NewCartRepository(dbCon)
-	cartService := services.NewCartService(cartRepository)
+	passwordPolicy, err := newPasswordPolicy(config.PasswordPolicy)
+	if err != nil {
+		return fmt.Errorf("run: error create password policy %w", err)
+	}
+
+	lockoutPolicy := sm.LockoutPolicy{
+		MaxAccountAttempts: config.LoginMaxAccountAttempts,
+		MaxIPAttempts:      config.LoginMaxIPAttempts,
+		BaseLockout:        config.LoginLockoutBase,
+		MaxLockout:         config.LoginLockoutMax,
+	}
+	keySet, err := services.NewKeySet(config.JWTActiveKeyID, config.JWTKeys)
+	if err != nil {
+		return fmt.Errorf("run: error load jwt keys %w", err)
+	}
 
 	tokenRepository := repositories.NewTokenRepository(dbCon)
-	jwtService := services.NewJWTService(tokenRepository)
+	jwtService := services.NewJWTService(tokenRepository, userRepository, keySet, config.JWTIssuer, config.JWTAudience, config.AccessTokenTTL, config.RefreshTokenTTL)
+
+	userService := services.NewUserService(userRepository, auditRepository, loginThrottleRepository, passwordHasher,
+		passwordPolicy, lockoutPolicy, jwtService)
 
-	httpServer := handlers.NewHttpServer(bookService, categoryService, userService, cartService, jwtService)
+	cartRepository := repositories.NewCartRepository(dbCon)
+	cartService := services.NewCartService(cartRepository, bookService, userService,
+		config.CartReservationTTL, config.RequireVerifiedEmailForCheckout)
+
+	orderRepository := repositories.NewOrderRepository(dbCon)
+	orderService := services.NewOrderService(orderRepository)
+
+	roleRepository := repositories.NewRoleRepository(dbCon)
+	roleService := services.NewRoleService(roleRepository)
+
+	mailSender, err := newMailer(config.Mail)
+	if err != nil {
+		return fmt.Errorf("run: error create mailer %w", err)
+	}
+
+	emailVerificationRepository := repositories.NewEmailVerificationRepository(dbCon)
+	passwordResetRepository := repositories.NewPasswordResetRepository(dbCon)
+	accountService := services.NewAccountService(emailVerificationRepository, passwordResetRepository, userRepository,
+		loginThrottleRepository, passwordHasher, passwordPolicy, lockoutPolicy, mailSender, jwtService,
+		config.PublicBaseURL, config.EmailVerificationTTL, config.PasswordResetTTL)
+
+	secretBox, err := secretbox.New(config.TOTPEncryptionKey)
+	if err != nil {
+		return fmt.Errorf("run: error create secret box %w", err)
+	}
+
+	twoFactorRepository := repositories.NewTwoFactorRepository(dbCon)
+	twoFactorService := services.NewTwoFactorService(twoFactorRepository, userRepository, loginThrottleRepository,
+		secretBox, jwtService, config.TOTPIssuer, config.TwoFactorChallengeTTL, lockoutPolicy)
+
+	httpServer := handlers.NewHttpServer(bookService, categoryService, userService, cartService, jwtService, orderService,
+		roleService, accountService, twoFactorService)
+
+	booksWrite := httpServer.RequirePermission(sm.PermissionBooksWrite)
+	categoriesWrite := httpServer.RequirePermission(sm.PermissionCategoriesWrite)
+	stockRead := httpServer.RequirePermission(sm.PermissionStockRead)
+	ordersRead := httpServer.RequirePermission(sm.PermissionOrdersRead)
+	usersManage := httpServer.RequirePermission(sm.PermissionUsersManage)
+
+	rateLimitStore := ratelimit.NewMemoryStore()
+	limiter := ratelimit.New(rateLimitStore)
+	catalogLimit := limiter.Limit("catalog", ratelimit.PerMinute(120).WithBurst(30), ratelimit.ByIP)
+	signInLimit := limiter.Limit("signin", ratelimit.PerMinute(10), ratelimit.ByIP)
+	signInTwoFactorLimit := limiter.Limit("signin-2fa", ratelimit.PerMinute(10), ratelimit.ByIP)
+	signUpLimit := limiter.Limit("signup", ratelimit.PerHour(20).WithBurst(5), ratelimit.ByIP)
+	refreshLimit := limiter.Limit("token-refresh", ratelimit.PerMinute(30), ratelimit.ByIP)
+	cartLimit := limiter.Limit("cart", ratelimit.PerMinute(60).WithBurst(20), ratelimit.ByUser)
+	verifyEmailLimit := limiter.Limit("verify-email", ratelimit.PerMinute(10), ratelimit.ByIP)
+	resendEmailLimit := limiter.Limit("resend-email", ratelimit.PerHour(5), ratelimit.ByUser)
+	profileLimit := limiter.Limit("profile", ratelimit.PerMinute(60).WithBurst(20), ratelimit.ByUser)
+	twoFactorSetupLimit := limiter.Limit("2fa-setup", ratelimit.PerHour(20).WithBurst(5), ratelimit.ByUser)
+	passwordLimit := limiter.Limit("password", ratelimit.PerHour(10).WithBurst(5), ratelimit.ByIP)
+	passwordChangeLimit := limiter.Limit("password-change", ratelimit.PerHour(10).WithBurst(5), ratelimit.ByUser)
 
 	router := mux.NewRouter()
 
-	router.HandleFunc("/book/{book_id}", httpServer.GetBook).Methods(http.MethodGet)
-	router.HandleFunc("/books", httpServer.GetBooksByCategories).Methods(http.MethodGet)
-	router.HandleFunc("/book", httpServer.CheckAdmin(httpServer.CreateBook)).Methods(http.MethodPost)
-	router.HandleFunc("/book/{book_id}", httpServer.CheckAdmin(httpServer.UpdateBook)).Methods(http.MethodPut)
-	router.HandleFunc("/book/{book_id}", httpServer.CheckAdmin(httpServer.DeleteBook)).Methods(http.MethodDelete)
+	router.HandleFunc("/book/{book_id}", catalogLimit(httpServer.GetBook)).Methods(http.MethodGet)
+	router.HandleFunc("/books", catalogLimit(httpServer.GetBooks)).Methods(http.MethodGet)
+	router.HandleFunc("/books/search", catalogLimit(httpServer.SearchBooks)).Methods(http.MethodGet)
+	router.HandleFunc("/book", booksWrite(httpServer.CreateBook)).Methods(http.MethodPost)
+	router.HandleFunc("/book/{book_id}", booksWrite(httpServer.UpdateBook)).Methods(http.MethodPut)
+	router.HandleFunc("/book/{book_id}", booksWrite(httpServer.DeleteBook)).Methods(http.MethodDelete)
+	router.HandleFunc("/book/{book_id}/restock", booksWrite(httpServer.RestockBook)).Methods(http.MethodPost)
+	router.HandleFunc("/book/{book_id}/stock-history", stockRead(httpServer.GetStockHistory)).Methods(http.MethodGet)
+
+	router.HandleFunc("/category", categoriesWrite(httpServer.CreateCategory)).Methods(http.MethodPost)
+	router.HandleFunc("/categories", catalogLimit(httpServer.GetCategories)).Methods(http.MethodGet)
+	router.HandleFunc("/category/{category_id}", categoriesWrite(httpServer.GetCategory)).Methods(http.MethodGet)
+	router.HandleFunc("/category/{category_id}", categoriesWrite(httpServer.UpdateCategory)).Methods(http.MethodPut)
+	router.HandleFunc("/category/{category_id}", categoriesWrite(httpServer.DeleteCategory)).Methods(http.MethodDelete)
 
-	router.HandleFunc("/category", httpServer.CheckAdmin(httpServer.CreateCategory)).Methods(http.MethodPost)
-	router.HandleFunc("/categories", httpServer.GetCategories).Methods(http.MethodGet)
-	router.HandleFunc("/category/{category_id}", httpServer.CheckAdmin(httpServer.GetCategory)).Methods(http.MethodGet)
-	router.HandleFunc("/category/{category_id}", httpServer.CheckAdmin(httpServer.UpdateCategory)).Methods(http.MethodPut)
-	router.HandleFunc("/category/{category_id}", httpServer.CheckAdmin(httpServer.DeleteCategory)).Methods(http.MethodDelete)
+	router.HandleFunc("/cart", httpServer.CheckAuthorizedUser(httpServer.GetCart)).Methods(http.MethodGet)
+	router.HandleFunc("/cart/add", httpServer.CheckAuthorizedUser(cartLimit(httpServer.AddToCart))).Methods(http.MethodPost)
+	router.HandleFunc("/cart/checkout", httpServer.CheckAuthorizedUser(httpServer.Checkout)).Methods(http.MethodPost)
+	router.HandleFunc("/cart/items/{book_id}", httpServer.CheckAuthorizedUser(httpServer.RemoveFromCart)).Methods(http.MethodDelete)
 
-	router.HandleFunc("/cart/add", httpServer.CheckAuthorizedUser(httpServer.AddToCart)).Methods(http.MethodPost)
+	router.HandleFunc("/orders", httpServer.CheckAuthorizedUser(httpServer.GetOrders)).Methods(http.MethodGet)
+	router.HandleFunc("/orders/{order_id}", httpServer.CheckAuthorizedUser(httpServer.GetOrder)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/orders/{order_id}", ordersRead(httpServer.GetAnyOrder)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/users/{user_id}/orders", ordersRead(httpServer.GetUserOrders)).Methods(http.MethodGet)
 
-	router.HandleFunc("/signup", httpServer.SignUp).Methods(http.MethodPost)
-	router.HandleFunc("/signin", httpServer.SignIn).Methods(http.MethodPost)
+	router.HandleFunc("/admin/users", usersManage(httpServer.GetUsers)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/users/{user_id}", usersManage(httpServer.GetUser)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/users/{user_id}/admin", usersManage(httpServer.GrantAdmin)).Methods(http.MethodPost)
+	router.HandleFunc("/admin/users/{user_id}/admin", usersManage(httpServer.RevokeAdmin)).Methods(http.MethodDelete)
+	router.HandleFunc("/admin/users/{user_id}/disable", usersManage(httpServer.DisableUser)).Methods(http.MethodPost)
+	router.HandleFunc("/admin/users/{user_id}/enable", usersManage(httpServer.EnableUser)).Methods(http.MethodPost)
+	router.HandleFunc("/admin/users/{user_id}/logout", usersManage(httpServer.ForceLogout)).Methods(http.MethodPost)
+	router.HandleFunc("/admin/users/{user_id}/lockout", usersManage(httpServer.UnlockUser)).Methods(http.MethodDelete)
+	router.HandleFunc("/admin/users/{user_id}/audit-log", usersManage(httpServer.GetUserAuditLog)).Methods(http.MethodGet)
+
+	router.HandleFunc("/signup", signUpLimit(httpServer.SignUp)).Methods(http.MethodPost)
+	router.HandleFunc("/signin", signInLimit(httpServer.SignIn)).Methods(http.MethodPost)
+	router.HandleFunc("/signin/2fa", signInTwoFactorLimit(httpServer.SignInTwoFactor)).Methods(http.MethodPost)
+	router.HandleFunc("/verify-email", verifyEmailLimit(httpServer.VerifyEmail)).Methods(http.MethodPost)
+	router.HandleFunc("/verify-email/resend", httpServer.CheckAuthorizedUser(resendEmailLimit(httpServer.ResendVerificationEmail))).Methods(http.MethodPost)
+	router.HandleFunc("/password/forgot", passwordLimit(httpServer.ForgotPassword)).Methods(http.MethodPost)
+	router.HandleFunc("/password/reset", passwordLimit(httpServer.ResetPassword)).Methods(http.MethodPost)
+	router.HandleFunc("/me", httpServer.CheckAuthorizedUser(profileLimit(httpServer.GetMe))).Methods(http.MethodGet)
+	router.HandleFunc("/me", httpServer.CheckAuthorizedUser(profileLimit(httpServer.UpdateMe))).Methods(http.MethodPatch)
+	router.HandleFunc("/me/sessions", httpServer.CheckAuthorizedUser(profileLimit(httpServer.GetMySessions))).Methods(http.MethodGet)
+	router.HandleFunc("/me/sessions/{session_id}", httpServer.CheckAuthorizedUser(profileLimit(httpServer.RevokeMySession))).Methods(http.MethodDelete)
+	router.HandleFunc("/me/password", httpServer.CheckAuthorizedUser(passwordChangeLimit(httpServer.ChangePassword))).Methods(http.MethodPost)
+	router.HandleFunc("/me/2fa/totp", httpServer.CheckAuthorizedUser(twoFactorSetupLimit(httpServer.BeginTOTPEnrollment))).Methods(http.MethodPost)
+	router.HandleFunc("/me/2fa/totp/confirm", httpServer.CheckAuthorizedUser(twoFactorSetupLimit(httpServer.ConfirmTOTPEnrollment))).Methods(http.MethodPost)
+	router.HandleFunc("/me/2fa/disable", httpServer.CheckAuthorizedUser(twoFactorSetupLimit(httpServer.DisableTwoFactor))).Methods(http.MethodPost)
+	router.HandleFunc("/.well-known/jwks.json", httpServer.GetJWKS).Methods(http.MethodGet)
+	router.HandleFunc("/token/refresh", refreshLimit(httpServer.RefreshToken)).Methods(http.MethodPost)
 	router.HandleFunc("/logout", httpServer.CheckAuthorizedUser(httpServer.Logout)).Methods(http.MethodPost)
 
 	srv := &http.Server{
@@ -101,6 +217,10 @@ func run() error {
 
 	cartService.CartCleanupScheduler()
 	jwtService.StartTokenCleanupScheduler()
+	roleService.PermissionCacheCleanupScheduler()
+	userService.LoginThrottleCleanupScheduler()
+	twoFactorService.ChallengeCleanupScheduler()
+	rateLimitStore.CleanupScheduler()
 
 	// listen to OS signals and gracefully shutdown HTTP server
 	stopped := make(chan struct{})
@@ -147,3 +267,39 @@ func RunMigrations(dsn string, migrationsPath string) error {
 
 	return nil
 }
+
+func newMailer(config conf.MailConfig) (mailer.Mailer, error) {
+	if config.Driver == "smtp" {
+		return mailer.NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword,
+			config.From, config.SMTPTimeout)
+	}
+	return mailer.NewLogMailer(config.From, config.LogDir), nil
+}
+
+// newPasswordHasher hashes with argon2id and still accepts the bcrypt hashes written before it,
+// upgrading them as their owners sign in.
+func newPasswordHasher(config conf.PasswordHashConfig) (*password.Versioned, error) {
+	argon2id, err := password.NewArgon2idHasher(password.Argon2idParams{
+		Memory:      uint32(config.Argon2Memory),
+		Iterations:  uint32(config.Argon2Iterations),
+		Parallelism: uint8(config.Argon2Parallelism),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return password.NewVersioned(argon2id, password.NewBcryptHasher(bcrypt.DefaultCost)), nil
+}
+
+func newPasswordPolicy(config conf.PasswordPolicyConfig) (*password.Policy, error) {
+	classes := make([]password.CharacterClass, 0, len(config.RequiredClasses))
+	for _, name := range config.RequiredClasses {
+		class, err := password.ParseCharacterClass(name)
+		if err != nil {
+			return nil, err
+		}
+		classes = append(classes, class)
+	}
+
+	return password.NewPolicy(config.MinLength, config.MaxLength, classes, config.CommonPasswordsPath)
+}
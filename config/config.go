@@ -1,8 +1,12 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,6 +18,72 @@ type Config struct {
 	MigrationsPath string
 	HttpPort       string
 	HttpHost       string
+
+	CartReservationTTL time.Duration
+	AccessTokenTTL     time.Duration
+	RefreshTokenTTL    time.Duration
+
+	LoginMaxAccountAttempts int
+	LoginMaxIPAttempts      int
+	LoginLockoutBase        time.Duration
+	LoginLockoutMax         time.Duration
+
+	PublicBaseURL                   string
+	EmailVerificationTTL            time.Duration
+	PasswordResetTTL                time.Duration
+	RequireVerifiedEmailForCheckout bool
+	Mail                            MailConfig
+
+	PasswordHash   PasswordHashConfig
+	PasswordPolicy PasswordPolicyConfig
+
+	JWTIssuer      string
+	JWTAudience    string
+	JWTActiveKeyID string
+	JWTKeys        []JWTKey
+
+	TOTPIssuer            string
+	TOTPEncryptionKey     []byte
+	TwoFactorChallengeTTL time.Duration
+}
+
+// MailConfig selects the mailer: "smtp" sends through the SMTP server, "log" only logs
+// messages and writes them to LogDir when it is set.
+type MailConfig struct {
+	Driver       string
+	From         string
+	LogDir       string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPTimeout  time.Duration
+}
+
+// PasswordHashConfig holds the argon2id parameters for new password hashes. Memory is in KiB.
+// Changing them makes existing hashes be rehashed the next time their owners sign in.
+type PasswordHashConfig struct {
+	Argon2Memory      int
+	Argon2Iterations  int
+	Argon2Parallelism int
+}
+
+// PasswordPolicyConfig restricts new passwords. RequiredClasses names the character classes
+// (lower, upper, digit, symbol) every password must contain, and CommonPasswordsPath a file
+// of passwords that are refused; both may be empty.
+type PasswordPolicyConfig struct {
+	MinLength           int
+	MaxLength           int
+	RequiredClasses     []string
+	CommonPasswordsPath string
+}
+
+// JWTKey is a signing key loaded from disk. Material holds the raw secret for HS256 and a
+// PEM-encoded private key for RS256 and EdDSA.
+type JWTKey struct {
+	ID        string
+	Algorithm string
+	Material  []byte
 }
 
 func LoadConfig() (Config, error) {
@@ -52,13 +122,139 @@ func LoadConfig() (Config, error) {
 		return Config{}, fmt.Errorf("can not download MIGRATIONS_PATH")
 	}
 
+	cartReservationTTL, err := downloadDuration("CART_RESERVATION_TTL")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download CART_RESERVATION_TTL")
+	}
+
+	accessTokenTTL, err := downloadDuration("ACCESS_TOKEN_TTL")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download ACCESS_TOKEN_TTL")
+	}
+
+	refreshTokenTTL, err := downloadDuration("REFRESH_TOKEN_TTL")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download REFRESH_TOKEN_TTL")
+	}
+
+	loginMaxAccountAttempts, err := downloadInt("LOGIN_MAX_ACCOUNT_ATTEMPTS")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download LOGIN_MAX_ACCOUNT_ATTEMPTS")
+	}
+
+	loginMaxIPAttempts, err := downloadInt("LOGIN_MAX_IP_ATTEMPTS")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download LOGIN_MAX_IP_ATTEMPTS")
+	}
+
+	loginLockoutBase, err := downloadDuration("LOGIN_LOCKOUT_BASE")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download LOGIN_LOCKOUT_BASE")
+	}
+
+	loginLockoutMax, err := downloadDuration("LOGIN_LOCKOUT_MAX")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download LOGIN_LOCKOUT_MAX")
+	}
+
+	publicBaseURL, err := downloadString("PUBLIC_BASE_URL")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download PUBLIC_BASE_URL")
+	}
+
+	emailVerificationTTL, err := downloadDuration("EMAIL_VERIFICATION_TTL")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download EMAIL_VERIFICATION_TTL")
+	}
+
+	passwordResetTTL, err := downloadDuration("PASSWORD_RESET_TTL")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download PASSWORD_RESET_TTL")
+	}
+
+	requireVerifiedEmail, err := downloadBool("REQUIRE_VERIFIED_EMAIL_FOR_CHECKOUT")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download REQUIRE_VERIFIED_EMAIL_FOR_CHECKOUT")
+	}
+
+	mail, err := downloadMailConfig()
+	if err != nil {
+		return Config{}, err
+	}
+
+	passwordHash, err := downloadPasswordHashConfig()
+	if err != nil {
+		return Config{}, err
+	}
+
+	passwordPolicy, err := downloadPasswordPolicyConfig()
+	if err != nil {
+		return Config{}, err
+	}
+
+	jwtIssuer, err := downloadString("JWT_ISSUER")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download JWT_ISSUER")
+	}
+
+	jwtAudience, err := downloadString("JWT_AUDIENCE")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download JWT_AUDIENCE")
+	}
+
+	jwtActiveKeyID, err := downloadString("JWT_ACTIVE_KEY_ID")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download JWT_ACTIVE_KEY_ID")
+	}
+
+	jwtKeys, err := downloadJWTKeys("JWT_KEYS")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download JWT_KEYS: %w", err)
+	}
+
+	totpIssuer, err := downloadString("TOTP_ISSUER")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download TOTP_ISSUER")
+	}
+
+	totpEncryptionKey, err := downloadSecretKey("TOTP_ENCRYPTION_KEY")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download TOTP_ENCRYPTION_KEY: %w", err)
+	}
+
+	twoFactorChallengeTTL, err := downloadDuration("TWO_FACTOR_CHALLENGE_TTL")
+	if err != nil {
+		return Config{}, fmt.Errorf("can not download TWO_FACTOR_CHALLENGE_TTL")
+	}
+
 	return Config{
-		Environment:    env,
-		DSN:            dsn,
-		HttpPort:       httpPort,
-		HttpHost:       httpHost,
-		LogLevel:       logLevel,
-		MigrationsPath: migrationsPath,
+		Environment:                     env,
+		DSN:                             dsn,
+		HttpPort:                        httpPort,
+		HttpHost:                        httpHost,
+		LogLevel:                        logLevel,
+		MigrationsPath:                  migrationsPath,
+		CartReservationTTL:              cartReservationTTL,
+		AccessTokenTTL:                  accessTokenTTL,
+		RefreshTokenTTL:                 refreshTokenTTL,
+		LoginMaxAccountAttempts:         loginMaxAccountAttempts,
+		LoginMaxIPAttempts:              loginMaxIPAttempts,
+		LoginLockoutBase:                loginLockoutBase,
+		LoginLockoutMax:                 loginLockoutMax,
+		PublicBaseURL:                   publicBaseURL,
+		EmailVerificationTTL:            emailVerificationTTL,
+		PasswordResetTTL:                passwordResetTTL,
+		RequireVerifiedEmailForCheckout: requireVerifiedEmail,
+		Mail:                            mail,
+		PasswordHash:                    passwordHash,
+		PasswordPolicy:                  passwordPolicy,
+		JWTIssuer:                       jwtIssuer,
+		JWTAudience:                     jwtAudience,
+		JWTActiveKeyID:                  jwtActiveKeyID,
+		JWTKeys:                         jwtKeys,
+		TOTPIssuer:                      totpIssuer,
+		TOTPEncryptionKey:               totpEncryptionKey,
+		TwoFactorChallengeTTL:           twoFactorChallengeTTL,
 	}, nil
 }
 
@@ -68,3 +264,174 @@ func downloadString(key string) (string, error) {
 	}
 	return "", fmt.Errorf("not found value by key %v", key)
 }
+
+func downloadMailConfig() (MailConfig, error) {
+	var mail MailConfig
+	var err error
+
+	if mail.Driver, err = downloadString("MAIL_DRIVER"); err != nil {
+		return MailConfig{}, fmt.Errorf("can not download MAIL_DRIVER")
+	}
+	if mail.From, err = downloadString("MAIL_FROM"); err != nil {
+		return MailConfig{}, fmt.Errorf("can not download MAIL_FROM")
+	}
+
+	switch mail.Driver {
+	case "log":
+		mail.LogDir = os.Getenv("MAIL_LOG_DIR")
+	case "smtp":
+		if mail.SMTPHost, err = downloadString("SMTP_HOST"); err != nil {
+			return MailConfig{}, fmt.Errorf("can not download SMTP_HOST")
+		}
+		if mail.SMTPPort, err = downloadString("SMTP_PORT"); err != nil {
+			return MailConfig{}, fmt.Errorf("can not download SMTP_PORT")
+		}
+		mail.SMTPUsername = os.Getenv("SMTP_USERNAME")
+		mail.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+		if mail.SMTPTimeout, err = downloadDuration("SMTP_TIMEOUT"); err != nil {
+			return MailConfig{}, fmt.Errorf("can not download SMTP_TIMEOUT: %w", err)
+		}
+	default:
+		return MailConfig{}, fmt.Errorf("unknown MAIL_DRIVER %v", mail.Driver)
+	}
+
+	return mail, nil
+}
+
+func downloadPasswordHashConfig() (PasswordHashConfig, error) {
+	var hash PasswordHashConfig
+	var err error
+
+	if hash.Argon2Memory, err = downloadInt("PASSWORD_ARGON2_MEMORY_KIB"); err != nil {
+		return PasswordHashConfig{}, fmt.Errorf("can not download PASSWORD_ARGON2_MEMORY_KIB")
+	}
+	if hash.Argon2Iterations, err = downloadInt("PASSWORD_ARGON2_ITERATIONS"); err != nil {
+		return PasswordHashConfig{}, fmt.Errorf("can not download PASSWORD_ARGON2_ITERATIONS")
+	}
+	if hash.Argon2Parallelism, err = downloadInt("PASSWORD_ARGON2_PARALLELISM"); err != nil {
+		return PasswordHashConfig{}, fmt.Errorf("can not download PASSWORD_ARGON2_PARALLELISM")
+	}
+	if hash.Argon2Parallelism > 255 {
+		return PasswordHashConfig{}, fmt.Errorf("PASSWORD_ARGON2_PARALLELISM must be at most 255")
+	}
+
+	return hash, nil
+}
+
+func downloadPasswordPolicyConfig() (PasswordPolicyConfig, error) {
+	var policy PasswordPolicyConfig
+	var err error
+
+	if policy.MinLength, err = downloadInt("PASSWORD_MIN_LENGTH"); err != nil {
+		return PasswordPolicyConfig{}, fmt.Errorf("can not download PASSWORD_MIN_LENGTH")
+	}
+	if policy.MaxLength, err = downloadInt("PASSWORD_MAX_LENGTH"); err != nil {
+		return PasswordPolicyConfig{}, fmt.Errorf("can not download PASSWORD_MAX_LENGTH")
+	}
+
+	for _, class := range strings.Split(os.Getenv("PASSWORD_REQUIRED_CHARACTER_CLASSES"), ",") {
+		if class = strings.TrimSpace(class); class != "" {
+			policy.RequiredClasses = append(policy.RequiredClasses, class)
+		}
+	}
+	policy.CommonPasswordsPath = os.Getenv("PASSWORD_COMMON_LIST")
+
+	return policy, nil
+}
+
+func downloadBool(key string) (bool, error) {
+	val, err := downloadString(key)
+	if err != nil {
+		return false, err
+	}
+
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("invalid bool by key %v: %w", key, err)
+	}
+	return b, nil
+}
+
+func downloadInt(key string) (int, error) {
+	val, err := downloadString(key)
+	if err != nil {
+		return 0, err
+	}
+
+	number, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number by key %v: %w", key, err)
+	}
+	if number <= 0 {
+		return 0, fmt.Errorf("number by key %v must be positive", key)
+	}
+	return number, nil
+}
+
+func downloadDuration(key string) (time.Duration, error) {
+	val, err := downloadString(key)
+	if err != nil {
+		return 0, err
+	}
+
+	duration, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration by key %v: %w", key, err)
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("duration by key %v must be positive", key)
+	}
+	return duration, nil
+}
+
+// downloadSecretKey reads a base64-encoded key from the file named by key.
+func downloadSecretKey(key string) ([]byte, error) {
+	path, err := downloadString(key)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %v: %w", path, err)
+	}
+
+	material, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 in key %v: %w", path, err)
+	}
+	return material, nil
+}
+
+// downloadJWTKeys reads a comma-separated list of "kid:algorithm:path" entries and loads each key file.
+func downloadJWTKeys(key string) ([]JWTKey, error) {
+	val, err := downloadString(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []JWTKey
+	for _, entry := range strings.Split(val, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid key entry %q, expected kid:algorithm:path", entry)
+		}
+
+		material, err := os.ReadFile(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key %v: %w", parts[0], err)
+		}
+
+		keys = append(keys, JWTKey{ID: parts[0], Algorithm: parts[1], Material: material})
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys configured by key %v", key)
+	}
+	return keys, nil
+}
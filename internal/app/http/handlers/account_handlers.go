@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	he "github.com/AnatolyGolang/book-shop/internal/app/http/handlers/errors"
+	"github.com/AnatolyGolang/book-shop/internal/app/http/handlers/models"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
+	"github.com/AnatolyGolang/book-shop/internal/app/utils"
+)
+
+func (h HttpServer) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var verifyRequest models.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&verifyRequest); err != nil {
+		he.BadRequest("invalid-json", err, w, r)
+		return
+	}
+
+	if err := verifyRequest.Validate(); err != nil {
+		respondInvalidRequest(err, w, r)
+		return
+	}
+
+	user, err := h.accountService.VerifyEmail(r.Context(), verifyRequest.Token)
+	if err != nil {
+		if errors.Is(err, se.ErrInvalidVerificationToken) {
+			he.BadRequest("invalid-verification-token", err, w, r)
+			return
+		}
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	he.RespondOK(models.ToUserResponse(user), w)
+}
+
+func (h HttpServer) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	user, err := sm.GetUserFromContext(r.Context())
+	if err != nil {
+		he.Unauthorised("unauthorized", err, w, r)
+		return
+	}
+
+	err = h.accountService.SendVerificationEmail(r.Context(), user.Id)
+	if err != nil {
+		if errors.Is(err, se.ErrEmailAlreadyVerified) {
+			he.Conflict("email-already-verified", err, w, r)
+			return
+		}
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	he.RespondOK(map[string]bool{"ok": true}, w)
+}
+
+func (h HttpServer) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var forgotRequest models.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&forgotRequest); err != nil {
+		he.BadRequest("invalid-json", err, w, r)
+		return
+	}
+
+	if err := forgotRequest.Validate(); err != nil {
+		respondInvalidRequest(err, w, r)
+		return
+	}
+
+	// The response is the same whether or not the email belongs to an account.
+	h.accountService.RequestPasswordReset(r.Context(), forgotRequest.Email)
+
+	he.RespondOK(map[string]bool{"ok": true}, w)
+}
+
+func (h HttpServer) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var resetRequest models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&resetRequest); err != nil {
+		he.BadRequest("invalid-json", err, w, r)
+		return
+	}
+
+	if err := resetRequest.Validate(); err != nil {
+		respondInvalidRequest(err, w, r)
+		return
+	}
+
+	err := h.accountService.ResetPassword(r.Context(), resetRequest.Token, resetRequest.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, se.ErrValidation):
+			respondInvalidRequest(err, w, r)
+		case errors.Is(err, se.ErrInvalidResetToken):
+			he.BadRequest("invalid-reset-token", err, w, r)
+		default:
+			he.RespondWithError(err, w, r)
+		}
+		return
+	}
+
+	he.RespondOK(map[string]bool{"ok": true}, w)
+}
+
+func (h HttpServer) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	user, err := sm.GetUserFromContext(r.Context())
+	if err != nil {
+		he.Unauthorised("unauthorized", err, w, r)
+		return
+	}
+
+	var changeRequest models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&changeRequest); err != nil {
+		he.BadRequest("invalid-json", err, w, r)
+		return
+	}
+
+	if err := changeRequest.Validate(); err != nil {
+		respondInvalidRequest(err, w, r)
+		return
+	}
+
+	err = h.accountService.ChangePassword(r.Context(), user.Id, changeRequest.CurrentPassword, changeRequest.NewPassword,
+		utils.ClientIP(r))
+	if err != nil {
+		var lockedErr *se.LockedError
+		switch {
+		case errors.As(err, &lockedErr):
+			w.Header().Set("Retry-After", utils.RetryAfterSeconds(time.Until(lockedErr.Until)))
+			he.TooManyRequests("too-many-attempts", err, w, r)
+		case errors.Is(err, se.ErrValidation):
+			respondInvalidRequest(err, w, r)
+		case errors.Is(err, se.ErrInvalidCurrentPassword):
+			he.BadRequest("invalid-current-password", err, w, r)
+		default:
+			he.RespondWithError(err, w, r)
+		}
+		return
+	}
+
+	he.RespondOK(map[string]bool{"ok": true}, w)
+}
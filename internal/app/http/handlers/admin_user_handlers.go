@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	he "github.com/AnatolyGolang/book-shop/internal/app/http/handlers/errors"
+	"github.com/AnatolyGolang/book-shop/internal/app/http/handlers/models"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+
+	"github.com/gorilla/mux"
+)
+
+func (h HttpServer) GetUsers(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r.URL.Query())
+	offset := (page - 1) * limit
+	search := strings.TrimSpace(r.URL.Query().Get("search"))
+
+	users, total, err := h.userService.GetUsers(r.Context(), search, limit, offset)
+	if err != nil {
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	response := models.UsersPaginationResponse{
+		Users: models.ToUsersResponse(users),
+		Meta: models.PaginationMeta{
+			Page:  page,
+			Limit: limit,
+			Total: &total,
+		},
+	}
+
+	he.RespondOK(response, w)
+}
+
+func (h HttpServer) GetUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromPath(r)
+	if err != nil {
+		he.BadRequest("invalid-user-id", err, w, r)
+		return
+	}
+
+	user, err := h.userService.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondUserError(err, w, r)
+		return
+	}
+
+	lockout, err := h.userService.GetLockout(r.Context(), userID)
+	if err != nil {
+		respondUserError(err, w, r)
+		return
+	}
+
+	response := models.ToUserResponse(user)
+	response.Lockout = models.ToLockoutResponse(lockout)
+	he.RespondOK(response, w)
+}
+
+func (h HttpServer) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromPath(r)
+	if err != nil {
+		he.BadRequest("invalid-user-id", err, w, r)
+		return
+	}
+
+	if err := h.userService.UnlockUser(r.Context(), userID); err != nil {
+		respondUserError(err, w, r)
+		return
+	}
+
+	he.RespondNoContent(w)
+}
+
+func (h HttpServer) GrantAdmin(w http.ResponseWriter, r *http.Request) {
+	h.setAdmin(true, w, r)
+}
+
+func (h HttpServer) RevokeAdmin(w http.ResponseWriter, r *http.Request) {
+	h.setAdmin(false, w, r)
+}
+
+func (h HttpServer) DisableUser(w http.ResponseWriter, r *http.Request) {
+	h.setDisabled(true, w, r)
+}
+
+func (h HttpServer) EnableUser(w http.ResponseWriter, r *http.Request) {
+	h.setDisabled(false, w, r)
+}
+
+func (h HttpServer) ForceLogout(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromPath(r)
+	if err != nil {
+		he.BadRequest("invalid-user-id", err, w, r)
+		return
+	}
+
+	sessions, err := h.userService.ForceLogout(r.Context(), userID)
+	if err != nil {
+		respondUserError(err, w, r)
+		return
+	}
+
+	he.RespondOK(models.ForceLogoutResponse{SessionsRevoked: sessions}, w)
+}
+
+func (h HttpServer) GetUserAuditLog(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromPath(r)
+	if err != nil {
+		he.BadRequest("invalid-user-id", err, w, r)
+		return
+	}
+
+	page, limit := parsePagination(r.URL.Query())
+	offset := (page - 1) * limit
+
+	entries, total, err := h.userService.GetAuditLog(r.Context(), userID, limit, offset)
+	if err != nil {
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	response := models.AuditLogResponse{
+		Entries: models.ToAuditEntriesResponse(entries),
+		Meta: models.PaginationMeta{
+			Page:  page,
+			Limit: limit,
+			Total: &total,
+		},
+	}
+
+	he.RespondOK(response, w)
+}
+
+func (h HttpServer) setAdmin(isAdmin bool, w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromPath(r)
+	if err != nil {
+		he.BadRequest("invalid-user-id", err, w, r)
+		return
+	}
+
+	user, err := h.userService.SetAdmin(r.Context(), userID, isAdmin)
+	if err != nil {
+		respondUserError(err, w, r)
+		return
+	}
+
+	he.RespondOK(models.ToUserResponse(user), w)
+}
+
+func (h HttpServer) setDisabled(disabled bool, w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromPath(r)
+	if err != nil {
+		he.BadRequest("invalid-user-id", err, w, r)
+		return
+	}
+
+	user, err := h.userService.SetDisabled(r.Context(), userID, disabled)
+	if err != nil {
+		respondUserError(err, w, r)
+		return
+	}
+
+	he.RespondOK(models.ToUserResponse(user), w)
+}
+
+func userIDFromPath(r *http.Request) (int, error) {
+	return strconv.Atoi(mux.Vars(r)["user_id"])
+}
+
+func respondUserError(err error, w http.ResponseWriter, r *http.Request) {
+	switch {
+	case errors.Is(err, se.ErrNotFound):
+		he.NotFound("user-not-found", err, w, r)
+	case errors.Is(err, se.ErrSelfAction):
+		he.Conflict("own-account", err, w, r)
+	case errors.Is(err, se.ErrNoUserInContext):
+		he.Unauthorised("unauthorized", err, w, r)
+	default:
+		he.RespondWithError(err, w, r)
+	}
+}
@@ -2,96 +2,163 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"strings"
+	"time"
 
-	"github.com/AnatolyGolang/book-shop/internal/app/http/handlers/errors"
+	he "github.com/AnatolyGolang/book-shop/internal/app/http/handlers/errors"
 	"github.com/AnatolyGolang/book-shop/internal/app/http/handlers/models"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
 	"github.com/AnatolyGolang/book-shop/internal/app/utils"
 )
 
 func (h HttpServer) SignUp(w http.ResponseWriter, r *http.Request) {
 	var authRequest models.AuthRequest
 	if err := json.NewDecoder(r.Body).Decode(&authRequest); err != nil {
-		errors.BadRequest("invalid-json", err, w, r)
+		he.BadRequest("invalid-json", err, w, r)
 		return
 	}
 
 	if err := authRequest.Validate(); err != nil {
-		errors.BadRequest("invalid-request", err, w, r)
+		respondInvalidRequest(err, w, r)
 		return
 	}
 
-	hashedPassword, err := utils.GetHash(authRequest.Password)
+	user, err := h.userService.CreateUser(r.Context(), models.ToDomainUser(authRequest.Email, authRequest.Password))
 	if err != nil {
-		errors.RespondWithError(err, w, r)
+		if errors.Is(err, se.ErrValidation) {
+			respondInvalidRequest(err, w, r)
+			return
+		}
+		he.RespondWithError(err, w, r)
 		return
 	}
 
-	_, err = h.userService.CreateUser(r.Context(), models.ToDomainUser(authRequest.Email, hashedPassword))
-	if err != nil {
-		errors.RespondWithError(err, w, r)
-		return
+	// The account exists at this point; if the email can not be sent, the user can ask for it again.
+	if err := h.accountService.SendVerificationEmail(r.Context(), user.Id); err != nil {
+		log.Printf("failed to send verification email to user %d: %v", user.Id, err)
 	}
 
-	errors.RespondOK(map[string]bool{"ok": true}, w)
+	he.RespondOK(map[string]bool{"ok": true}, w)
 }
 
 func (h HttpServer) SignIn(w http.ResponseWriter, r *http.Request) {
 	var authRequest models.AuthRequest
 	if err := json.NewDecoder(r.Body).Decode(&authRequest); err != nil {
-		errors.BadRequest("invalid-json", err, w, r)
+		he.BadRequest("invalid-json", err, w, r)
 		return
 	}
 
 	if err := authRequest.Validate(); err != nil {
-		errors.BadRequest("invalid-request", err, w, r)
+		respondInvalidRequest(err, w, r)
 		return
 	}
 
-	user, err := h.userService.GetUserByName(r.Context(), authRequest.Email)
+	user, err := h.userService.Authenticate(r.Context(), authRequest.Email, authRequest.Password, utils.ClientIP(r))
 	if err != nil {
-		errors.RespondWithError(err, w, r)
+		var lockedErr *se.LockedError
+		switch {
+		case errors.As(err, &lockedErr):
+			w.Header().Set("Retry-After", utils.RetryAfterSeconds(time.Until(lockedErr.Until)))
+			he.TooManyRequests("too-many-attempts", err, w, r)
+		case errors.Is(err, se.ErrInvalidCredentials):
+			he.Unauthorised("invalid-credentials", err, w, r)
+		case errors.Is(err, se.ErrAccountDisabled):
+			he.Unauthorised("account-disabled", err, w, r)
+		default:
+			he.RespondWithError(err, w, r)
+		}
 		return
 	}
 
-	if !utils.CheckHash(authRequest.Password, user.Password) {
-		errors.BadRequest("invalid-password", nil, w, r)
+	twoFactorEnabled, err := h.twoFactorService.IsEnabled(r.Context(), user.Id)
+	if err != nil {
+		he.RespondWithError(err, w, r)
 		return
 	}
 
-	token, err := h.jwtService.GenerateJWT(r.Context(), user)
+	// With two-factor authentication the password only earns a challenge, which POST /signin/2fa
+	// exchanges for tokens together with a code.
+	if twoFactorEnabled {
+		challenge, err := h.twoFactorService.StartChallenge(r.Context(), user.Id)
+		if err != nil {
+			he.RespondWithError(err, w, r)
+			return
+		}
+
+		he.RespondOK(models.ToTwoFactorChallengeResponse(challenge), w)
+		return
+	}
+
+	tokens, err := h.jwtService.IssueTokens(r.Context(), user, sm.NewDevice(r.UserAgent(), utils.ClientIP(r)))
 	if err != nil {
-		errors.RespondWithError(err, w, r)
+		he.RespondWithError(err, w, r)
 		return
 	}
 
-	errors.RespondOK(map[string]string{"token": token}, w)
+	he.RespondOK(models.ToTokenResponse(tokens), w)
+}
+
+func (h HttpServer) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var refreshRequest models.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&refreshRequest); err != nil {
+		he.BadRequest("invalid-json", err, w, r)
+		return
+	}
+
+	if err := refreshRequest.Validate(); err != nil {
+		respondInvalidRequest(err, w, r)
+		return
+	}
+
+	tokens, err := h.jwtService.RefreshTokens(r.Context(), refreshRequest.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, se.ErrRefreshTokenReused):
+			he.Unauthorised("refresh-token-reused", err, w, r)
+		case errors.Is(err, se.ErrInvalidRefreshToken):
+			he.Unauthorised("invalid-refresh-token", err, w, r)
+		case errors.Is(err, se.ErrAccountDisabled):
+			he.Unauthorised("account-disabled", err, w, r)
+		default:
+			he.RespondWithError(err, w, r)
+		}
+		return
+	}
+
+	he.RespondOK(models.ToTokenResponse(tokens), w)
 }
 
 func (h HttpServer) Logout(w http.ResponseWriter, r *http.Request) {
 	authHeader := r.Header.Get(utils.AuthorizationHeader)
 	if authHeader == "" {
-		errors.BadRequest("missing-token", nil, w, r)
+		he.BadRequest("missing-token", nil, w, r)
 		return
 	}
 
 	if !strings.HasPrefix(authHeader, utils.BearerPrefix) {
-		errors.BadRequest("invalid-token-format", nil, w, r)
+		he.BadRequest("invalid-token-format", nil, w, r)
 		return
 	}
 
 	token := strings.TrimSpace(strings.TrimPrefix(authHeader, utils.BearerPrefix))
 	if token == "" {
-		errors.BadRequest("empty-token", nil, w, r)
+		he.BadRequest("empty-token", nil, w, r)
 		return
 	}
 
 	err := h.jwtService.RevokeToken(r.Context(), token)
 	if err != nil {
-		errors.RespondWithError(err, w, r)
+		he.RespondWithError(err, w, r)
 		return
 	}
 
-	errors.RespondOK(map[string]string{"message": "Logged out successfully"}, w)
+	he.RespondOK(map[string]string{"message": "Logged out successfully"}, w)
+}
+
+func (h HttpServer) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	he.RespondOK(models.ToJWKSResponse(h.jwtService.PublicKeys()), w)
 }
@@ -2,47 +2,64 @@ package handlers
 
 import (
 	he "github.com/AnatolyGolang/book-shop/internal/app/http/handlers/errors"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
 	"github.com/AnatolyGolang/book-shop/internal/app/utils"
 
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 )
 
-func (h HttpServer) CheckAdmin(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get(utils.AuthorizationHeader)
-		token = strings.TrimSpace(strings.TrimPrefix(token, utils.BearerPrefix))
-		user, err := h.jwtService.GetUser(r.Context(), token)
+// RequirePermission lets the request through only if one of the user's roles grants the permission.
+// The permissions are put in the context next to the user.
+func (h HttpServer) RequirePermission(permission string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			user, err := h.userFromRequest(r)
+			if err != nil {
+				unauthorisedToken(err, w, r)
+				return
+			}
 
-		if err != nil {
-			he.InternalError("invalid-token", err, w, r)
-			return
-		}
+			if user.Email == "" {
+				he.InternalError("empty email in token", nil, w, r)
+				return
+			}
 
-		if user.Email == "" {
-			he.InternalError("empty email in token", nil, w, r)
-			return
-		}
+			permissions, err := h.roleService.GetPermissions(r.Context(), user.Id)
+			if err != nil {
+				he.RespondWithError(err, w, r)
+				return
+			}
 
-		if !user.IsAdmin {
-			he.Unauthorised("user not admin", nil, w, r)
-			return
-		}
+			if !permissions.Has(permission) {
+				he.Forbidden("missing-permission", nil, w, r)
+				return
+			}
 
-		ctx := context.WithValue(r.Context(), utils.ContextUserKey, user)
-		next(w, r.WithContext(ctx))
+			if err := h.twoFactorService.CheckRequirement(r.Context(), user.Id); err != nil {
+				if errors.Is(err, se.ErrTwoFactorRequired) {
+					he.Forbidden("two-factor-required", err, w, r)
+					return
+				}
+				he.RespondWithError(err, w, r)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), utils.ContextUserKey, user)
+			ctx = context.WithValue(ctx, utils.ContextPermissionsKey, permissions)
+			next(w, r.WithContext(ctx))
+		}
 	}
 }
 
 func (h HttpServer) CheckAuthorizedUser(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get(utils.AuthorizationHeader)
-		token = strings.TrimSpace(strings.TrimPrefix(token, utils.BearerPrefix))
-		user, err := h.jwtService.GetUser(r.Context(), token)
-
+		user, err := h.userFromRequest(r)
 		if err != nil {
-			he.InternalError("invalid-token", err, w, r)
+			unauthorisedToken(err, w, r)
 			return
 		}
 
@@ -55,3 +72,23 @@ func (h HttpServer) CheckAuthorizedUser(next http.HandlerFunc) http.HandlerFunc
 		next(w, r.WithContext(ctx))
 	}
 }
+
+// userFromRequest resolves the user behind the bearer token of the request, for handlers
+// that are public but behave differently for authenticated users.
+func (h HttpServer) userFromRequest(r *http.Request) (sm.DomainUser, error) {
+	token := r.Header.Get(utils.AuthorizationHeader)
+	token = strings.TrimSpace(strings.TrimPrefix(token, utils.BearerPrefix))
+	if token == "" {
+		return sm.DomainUser{}, errors.New("missing token")
+	}
+
+	return h.jwtService.GetUser(r.Context(), token)
+}
+
+func unauthorisedToken(err error, w http.ResponseWriter, r *http.Request) {
+	if errors.Is(err, se.ErrTokenRevoked) {
+		he.Unauthorised("token-revoked", err, w, r)
+		return
+	}
+	he.Unauthorised("invalid-token", err, w, r)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,15 +11,11 @@ import (
 	he "github.com/AnatolyGolang/book-shop/internal/app/http/handlers/errors"
 	"github.com/AnatolyGolang/book-shop/internal/app/http/handlers/models"
 	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
 
 	"github.com/gorilla/mux"
 )
 
-const (
-	MinLimit = 50
-	MaxLimit = 100
-)
-
 func (h HttpServer) GetBook(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	bookID, err := strconv.Atoi(vars["book_id"])
@@ -100,7 +97,7 @@ func (h HttpServer) UpdateBook(w http.ResponseWriter, r *http.Request) {
 	he.RespondOK(response, w)
 }
 
-func (h HttpServer) DeleteBook(w http.ResponseWriter, r *http.Request) {
+func (h HttpServer) RestockBook(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	bookID, err := strconv.Atoi(vars["book_id"])
 	if err != nil {
@@ -108,7 +105,18 @@ func (h HttpServer) DeleteBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.bookService.DeleteBook(r.Context(), bookID)
+	var req models.BookRestockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		he.BadRequest("invalid-request-body", err, w, r)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		he.BadRequest("validation-error", err, w, r)
+		return
+	}
+
+	book, err := h.bookService.RestockBook(r.Context(), bookID, req.Amount, strings.TrimSpace(req.Reason))
 	if err != nil {
 		if errors.Is(err, se.ErrNotFound) {
 			he.NotFound("book-not-found", err, w, r)
@@ -118,10 +126,11 @@ func (h HttpServer) DeleteBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	he.RespondNoContent(w)
+	response := models.ToBookResponse(book)
+	he.RespondOK(response, w)
 }
 
-func (h HttpServer) GetBooks(w http.ResponseWriter, r *http.Request) {
+func (h HttpServer) DeleteBook(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	bookID, err := strconv.Atoi(vars["book_id"])
 	if err != nil {
@@ -142,43 +151,32 @@ func (h HttpServer) GetBooks(w http.ResponseWriter, r *http.Request) {
 	he.RespondNoContent(w)
 }
 
-func (h HttpServer) GetBooksByCategories(w http.ResponseWriter, r *http.Request) {
+func (h HttpServer) GetBooks(w http.ResponseWriter, r *http.Request) {
 	queryParams := r.URL.Query()
 
-	categoryIDsReq, ok := queryParams["category_ids"]
-	if !ok || len(categoryIDsReq) == 0 {
-		he.BadRequest("missing-category-ids", errors.New("at least one categoryId must be provided"), w, r)
+	filter, err := models.ToBookFilter(queryParams)
+	if err != nil {
+		he.BadRequest("invalid-filter", err, w, r)
 		return
 	}
 
-	var categoryIDs []int
-	for _, idStr := range categoryIDsReq {
-		ids := strings.Split(idStr, ",")
-		for _, id := range ids {
-			categoryID, err := strconv.Atoi(strings.TrimSpace(id))
-			if err != nil {
-				he.BadRequest("invalid-category-id", err, w, r)
-				return
-			}
-			categoryIDs = append(categoryIDs, categoryID)
-		}
+	filter.IncludeSoldOut, err = h.includeSoldOut(r)
+	if err != nil {
+		respondIncludeSoldOutError(err, w, r)
+		return
 	}
 
-	page, err := strconv.Atoi(queryParams.Get("page"))
-	if err != nil || page < 1 {
-		page = 1
-	}
+	page, limit := parsePagination(queryParams)
+	filter.Limit = limit
 
-	limit, err := strconv.Atoi(queryParams.Get("limit"))
-	if err != nil || limit < MinLimit {
-		limit = MinLimit
-	} else if limit > MaxLimit {
-		limit = MaxLimit
+	if token := queryParams.Get("cursor"); token != "" {
+		h.getBooksByCursor(filter, token, w, r)
+		return
 	}
 
-	offset := (page - 1) * limit
+	filter.Offset = (page - 1) * limit
 
-	books, total, err := h.bookService.GetBooksByCategories(r.Context(), categoryIDs, limit, offset)
+	books, total, err := h.bookService.GetBooks(r.Context(), filter)
 	if err != nil {
 		if errors.Is(err, se.ErrNotFound) {
 			he.NotFound("books-not-found", err, w, r)
@@ -188,12 +186,165 @@ func (h HttpServer) GetBooksByCategories(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	meta := models.PaginationMeta{
+		Page:  page,
+		Limit: limit,
+		Total: &total,
+	}
+	meta.PrevCursor, meta.NextCursor = bookPageCursors(filter, books, filter.Offset > 0, filter.Offset+len(books) < total)
+
 	response := models.PaginationResponse{
 		Books: models.ToBooksResponse(books),
+		Meta:  meta,
+	}
+
+	he.RespondOK(response, w)
+}
+
+// getBooksByCursor serves the keyset-paginated variant of GetBooks, which skips OFFSET and COUNT(*).
+func (h HttpServer) getBooksByCursor(filter sm.BookFilter, token string, w http.ResponseWriter, r *http.Request) {
+	cursor, err := models.DecodeBookCursor(token, filter)
+	if err != nil {
+		he.BadRequest("invalid-cursor", err, w, r)
+		return
+	}
+
+	books, hasMore, err := h.bookService.GetBooksByCursor(r.Context(), filter, cursor)
+	if err != nil {
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	meta := models.PaginationMeta{
+		Limit: filter.Limit,
+	}
+	meta.PrevCursor, meta.NextCursor = bookPageCursors(filter, books, !cursor.Backward || hasMore, cursor.Backward || hasMore)
+
+	response := models.PaginationResponse{
+		Books: models.ToBooksResponse(books),
+		Meta:  meta,
+	}
+
+	he.RespondOK(response, w)
+}
+
+// bookPageCursors builds the tokens pointing before the first and after the last book of a page.
+func bookPageCursors(filter sm.BookFilter, books []sm.DomainBook, hasPrev bool, hasNext bool) (string, string) {
+	if len(books) == 0 {
+		return "", ""
+	}
+
+	var prev, next string
+	if hasPrev {
+		prev = models.EncodeBookCursor(sm.NewBookCursor(filter, books[0], true))
+	}
+	if hasNext {
+		next = models.EncodeBookCursor(sm.NewBookCursor(filter, books[len(books)-1], false))
+	}
+	return prev, next
+}
+
+func (h HttpServer) SearchBooks(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	text := strings.TrimSpace(queryParams.Get("q"))
+	if text == "" {
+		he.BadRequest("missing-query", errors.New("q is required"), w, r)
+		return
+	}
+
+	includeSoldOut, err := h.includeSoldOut(r)
+	if err != nil {
+		respondIncludeSoldOutError(err, w, r)
+		return
+	}
+
+	page, limit := parsePagination(queryParams)
+	offset := (page - 1) * limit
+
+	results, total, err := h.bookService.SearchBooks(r.Context(), text, includeSoldOut, limit, offset)
+	if err != nil {
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	response := models.SearchPaginationResponse{
+		Books: models.ToBookSearchResponse(results),
+		Meta: models.PaginationMeta{
+			Page:  page,
+			Limit: limit,
+			Total: &total,
+		},
+	}
+
+	he.RespondOK(response, w)
+}
+
+// errIncludeSoldOutForbidden is returned by includeSoldOut for signed-in users without the permission.
+var errIncludeSoldOutForbidden = errors.New("missing permission to include sold-out books")
+
+// includeSoldOut reports whether the listing should show sold-out books, which only admins may ask for.
+func (h HttpServer) includeSoldOut(r *http.Request) (bool, error) {
+	if r.URL.Query().Get("include_sold_out") != "true" {
+		return false, nil
+	}
+
+	user, err := h.userFromRequest(r)
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", se.ErrNoUserInContext, err)
+	}
+
+	permissions, err := h.roleService.GetPermissions(r.Context(), user.Id)
+	if err != nil {
+		return false, err
+	}
+	if !permissions.Has(sm.PermissionStockRead) {
+		return false, errIncludeSoldOutForbidden
+	}
+
+	return true, nil
+}
+
+// respondIncludeSoldOutError answers 401 to anonymous callers and 403 to signed-in ones who
+// may not see sold-out books.
+func respondIncludeSoldOutError(err error, w http.ResponseWriter, r *http.Request) {
+	switch {
+	case errors.Is(err, se.ErrNoUserInContext):
+		he.Unauthorised("include-sold-out-admin-only", err, w, r)
+	case errors.Is(err, errIncludeSoldOutForbidden):
+		he.Forbidden("include-sold-out-admin-only", err, w, r)
+	default:
+		he.RespondWithError(err, w, r)
+	}
+}
+
+func (h HttpServer) GetStockHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bookID, err := strconv.Atoi(vars["book_id"])
+	if err != nil {
+		he.BadRequest("invalid-book-id", err, w, r)
+		return
+	}
+
+	page, limit := parsePagination(r.URL.Query())
+	offset := (page - 1) * limit
+
+	movements, total, err := h.bookService.GetStockHistory(r.Context(), bookID, limit, offset)
+	if err != nil {
+		if errors.Is(err, se.ErrNotFound) {
+			he.NotFound("book-not-found", err, w, r)
+			return
+		}
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	response := models.StockHistoryResponse{
+		Movements: models.ToStockMovementsResponse(movements),
 		Meta: models.PaginationMeta{
 			Page:  page,
 			Limit: limit,
-			Total: total,
+			Total: &total,
 		},
 	}
 
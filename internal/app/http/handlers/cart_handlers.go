@@ -2,11 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	he "github.com/AnatolyGolang/book-shop/internal/app/http/handlers/errors"
 	"github.com/AnatolyGolang/book-shop/internal/app/http/handlers/models"
+	serr "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
 	se "github.com/AnatolyGolang/book-shop/internal/app/services/models"
+
+	"github.com/gorilla/mux"
 )
 
 func (h HttpServer) AddToCart(w http.ResponseWriter, r *http.Request) {
@@ -22,16 +27,91 @@ func (h HttpServer) AddToCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(cartReq.BookIds) <= 0 {
-		he.BadRequest("invalid-request-body", err, w, r)
+	if err := cartReq.Validate(); err != nil {
+		he.BadRequest("validation-error", err, w, r)
 		return
 	}
 
-	err = h.cartService.UpdateCart(r.Context(), user.Id, cartReq.BookIds)
+	err = h.cartService.UpdateCart(r.Context(), user.Id, models.ToServiceCartItems(cartReq))
 	if err != nil {
+		if errors.Is(err, serr.ErrOutOfStock) {
+			he.Conflict("book-out-of-stock", err, w, r)
+			return
+		}
+		if errors.Is(err, serr.ErrNotFound) {
+			he.NotFound("book-not-found", err, w, r)
+			return
+		}
 		he.RespondWithError(err, w, r)
 		return
 	}
 
 	he.RespondOK(map[string]string{"message": "Book added to cart"}, w)
 }
+
+func (h HttpServer) Checkout(w http.ResponseWriter, r *http.Request) {
+	user, err := se.GetUserFromContext(r.Context())
+	if err != nil {
+		he.Unauthorised("unauthorized", err, w, r)
+		return
+	}
+
+	order, err := h.cartService.Checkout(r.Context(), user.Id)
+	if err != nil {
+		if errors.Is(err, serr.ErrEmptyCart) {
+			he.BadRequest("empty-cart", err, w, r)
+			return
+		}
+		if errors.Is(err, serr.ErrEmailNotVerified) {
+			he.Forbidden("email-not-verified", err, w, r)
+			return
+		}
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	he.RespondCreated(models.ToOrderResponse(order), w)
+}
+
+func (h HttpServer) GetCart(w http.ResponseWriter, r *http.Request) {
+	user, err := se.GetUserFromContext(r.Context())
+	if err != nil {
+		he.Unauthorised("unauthorized", err, w, r)
+		return
+	}
+
+	cart, err := h.cartService.GetCart(r.Context(), user.Id)
+	if err != nil {
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	he.RespondOK(models.ToCartResponse(cart), w)
+}
+
+func (h HttpServer) RemoveFromCart(w http.ResponseWriter, r *http.Request) {
+	user, err := se.GetUserFromContext(r.Context())
+	if err != nil {
+		he.Unauthorised("unauthorized", err, w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bookID, err := strconv.Atoi(vars["book_id"])
+	if err != nil {
+		he.BadRequest("invalid-book-id", err, w, r)
+		return
+	}
+
+	err = h.cartService.RemoveFromCart(r.Context(), user.Id, bookID)
+	if err != nil {
+		if errors.Is(err, serr.ErrNotFound) {
+			he.NotFound("book-not-in-cart", err, w, r)
+			return
+		}
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	he.RespondNoContent(w)
+}
@@ -9,6 +9,7 @@ var (
 	ErrorTypeAuthorization = ErrorType{"authorization"}
 	ErrorTypeBadRequest    = ErrorType{"bad-request"}
 	ErrorTypeNotFound      = ErrorType{"not-found"}
+	ErrorTypeConflict      = ErrorType{"conflict"}
 )
 
 type SlugError struct {
@@ -60,3 +61,11 @@ func NewNotFoundError(error string, slug string) SlugError {
 		errorType: ErrorTypeNotFound,
 	}
 }
+
+func NewConflictError(error string, slug string) SlugError {
+	return SlugError{
+		error:     error,
+		slug:      slug,
+		errorType: ErrorTypeConflict,
+	}
+}
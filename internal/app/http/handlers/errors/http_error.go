@@ -24,6 +24,26 @@ func NotFound(slug string, err error, w http.ResponseWriter, r *http.Request) {
 	httpRespondWithError(err, slug, w, r, "Not found", http.StatusBadRequest)
 }
 
+func Forbidden(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Forbidden", http.StatusForbidden)
+}
+
+func Conflict(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Conflict", http.StatusConflict)
+}
+
+func TooManyRequests(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Too many requests", http.StatusTooManyRequests)
+}
+
+// InvalidFields responds with 400 and the reason each field was rejected.
+func InvalidFields(fields []FieldError, err error, w http.ResponseWriter, r *http.Request) {
+	log.Printf("error: %s, slug: %s, msg: %s", err, "invalid-request", "Bad request")
+
+	resp := ErrorResponse{Slug: "invalid-request", Fields: fields, httpStatus: http.StatusBadRequest}
+	writeErrorResponse(resp, err, w)
+}
+
 func RespondWithError(err error, w http.ResponseWriter, r *http.Request) {
 	var slugError SlugError
 	if !errors.As(err, &slugError) {
@@ -38,6 +58,8 @@ func RespondWithError(err error, w http.ResponseWriter, r *http.Request) {
 		BadRequest(slugError.Slug(), slugError, w, r)
 	case ErrorTypeNotFound:
 		NotFound(slugError.Slug(), slugError, w, r)
+	case ErrorTypeConflict:
+		Conflict(slugError.Slug(), slugError, w, r)
 	default:
 		InternalError(slugError.Slug(), slugError, w, r)
 	}
@@ -47,21 +69,32 @@ func httpRespondWithError(err error, slug string, w http.ResponseWriter, r *http
 	log.Printf("error: %s, slug: %s, msg: %s", err, slug, msg)
 
 	resp := ErrorResponse{Slug: slug, httpStatus: status}
+	writeErrorResponse(resp, err, w)
+}
+
+func writeErrorResponse(resp ErrorResponse, err error, w http.ResponseWriter) {
 	if os.Getenv("DEBUG_ERRORS") != "" && err != nil {
 		resp.Error = err.Error()
 	}
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(status)
+	w.WriteHeader(resp.httpStatus)
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
 type ErrorResponse struct {
-	Slug       string `json:"slug"`
-	Error      string `json:"error,omitempty"`
+	Slug       string       `json:"slug"`
+	Error      string       `json:"error,omitempty"`
+	Fields     []FieldError `json:"fields,omitempty"`
 	httpStatus int
 }
 
+type FieldError struct {
+	Field   string `json:"field"`
+	Slug    string `json:"slug"`
+	Message string `json:"message"`
+}
+
 func (e ErrorResponse) Render(w http.ResponseWriter, _ *http.Request) error {
 	w.WriteHeader(e.httpStatus)
 	return nil
@@ -3,11 +3,15 @@ package handlers
 import "github.com/AnatolyGolang/book-shop/internal/app/services"
 
 type HttpServer struct {
-	bookService     services.BookService
-	categoryService services.CategoryService
-	userService     services.UserService
-	cartService     services.CartService
-	jwtService      services.JWTService
+	bookService      services.BookService
+	categoryService  services.CategoryService
+	userService      services.UserService
+	cartService      services.CartService
+	jwtService       services.JWTService
+	orderService     services.OrderService
+	roleService      services.RoleService
+	accountService   services.AccountService
+	twoFactorService services.TwoFactorService
 }
 
 // NewHttpServer creates a new HTTP server for ports
@@ -15,12 +19,20 @@ func NewHttpServer(bs services.BookService,
 	cs services.CategoryService,
 	us services.UserService,
 	carts services.CartService,
-	jwts services.JWTService) HttpServer {
+	jwts services.JWTService,
+	os services.OrderService,
+	rs services.RoleService,
+	as services.AccountService,
+	tfs services.TwoFactorService) HttpServer {
 	return HttpServer{
-		bookService:     bs,
-		categoryService: cs,
-		userService:     us,
-		cartService:     carts,
-		jwtService:      jwts,
+		bookService:      bs,
+		categoryService:  cs,
+		userService:      us,
+		cartService:      carts,
+		jwtService:       jwts,
+		orderService:     os,
+		roleService:      rs,
+		accountService:   as,
+		twoFactorService: tfs,
 	}
 }
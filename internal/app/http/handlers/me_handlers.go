@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	he "github.com/AnatolyGolang/book-shop/internal/app/http/handlers/errors"
+	"github.com/AnatolyGolang/book-shop/internal/app/http/handlers/models"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
+
+	"github.com/gorilla/mux"
+)
+
+func (h HttpServer) GetMe(w http.ResponseWriter, r *http.Request) {
+	contextUser, err := sm.GetUserFromContext(r.Context())
+	if err != nil {
+		he.Unauthorised("unauthorized", err, w, r)
+		return
+	}
+
+	user, err := h.userService.GetUserById(r.Context(), contextUser.Id)
+	if err != nil {
+		respondUserError(err, w, r)
+		return
+	}
+
+	he.RespondOK(models.ToUserResponse(user), w)
+}
+
+func (h HttpServer) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	contextUser, err := sm.GetUserFromContext(r.Context())
+	if err != nil {
+		he.Unauthorised("unauthorized", err, w, r)
+		return
+	}
+
+	var updateRequest models.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&updateRequest); err != nil {
+		he.BadRequest("invalid-json", err, w, r)
+		return
+	}
+
+	if err := updateRequest.Validate(); err != nil {
+		respondInvalidRequest(err, w, r)
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(r.Context(), contextUser.Id, updateRequest.ToProfileUpdate())
+	if err != nil {
+		if errors.Is(err, se.ErrValidation) {
+			respondInvalidRequest(err, w, r)
+			return
+		}
+		respondUserError(err, w, r)
+		return
+	}
+
+	he.RespondOK(models.ToUserResponse(user), w)
+}
+
+func (h HttpServer) GetMySessions(w http.ResponseWriter, r *http.Request) {
+	user, err := sm.GetUserFromContext(r.Context())
+	if err != nil {
+		he.Unauthorised("unauthorized", err, w, r)
+		return
+	}
+
+	sessions, err := h.jwtService.GetSessions(r.Context(), user.Id)
+	if err != nil {
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	he.RespondOK(models.ToSessionsResponse(sessions), w)
+}
+
+func (h HttpServer) RevokeMySession(w http.ResponseWriter, r *http.Request) {
+	user, err := sm.GetUserFromContext(r.Context())
+	if err != nil {
+		he.Unauthorised("unauthorized", err, w, r)
+		return
+	}
+
+	err = h.jwtService.RevokeSession(r.Context(), user.Id, mux.Vars(r)["session_id"])
+	if err != nil {
+		if errors.Is(err, se.ErrNotFound) {
+			he.NotFound("session-not-found", err, w, r)
+			return
+		}
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	he.RespondNoContent(w)
+}
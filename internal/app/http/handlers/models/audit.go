@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
+)
+
+type AuditEntryResponse struct {
+	ID           int            `json:"id"`
+	ActorID      *int           `json:"actor_id"`
+	Action       string         `json:"action"`
+	TargetUserID *int           `json:"target_user_id"`
+	Details      map[string]any `json:"details"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+type AuditLogResponse struct {
+	Entries []AuditEntryResponse `json:"entries"`
+	Meta    PaginationMeta       `json:"meta"`
+}
+
+func ToAuditEntriesResponse(entries []models.DomainAuditEntry) []AuditEntryResponse {
+	response := make([]AuditEntryResponse, len(entries))
+	for i, e := range entries {
+		response[i] = AuditEntryResponse{
+			ID:           e.ID,
+			ActorID:      e.ActorID,
+			Action:       e.Action,
+			TargetUserID: e.TargetUserID,
+			Details:      e.Details,
+			CreatedAt:    e.CreatedAt,
+		}
+	}
+	return response
+}
@@ -1,6 +1,11 @@
 package models
 
-import "fmt"
+import (
+	"time"
+
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
+)
 
 type AuthRequest struct {
 	Email    string `json:"email"`
@@ -8,11 +13,84 @@ type AuthRequest struct {
 }
 
 func (r *AuthRequest) Validate() error {
-	if r.Email == "" {
-		return fmt.Errorf("email is required")
+	validationErr := &se.ValidationError{}
+	requireField(validationErr, "email", r.Email)
+	requireField(validationErr, "password", r.Password)
+	return validationErr.Err()
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+func (r *VerifyEmailRequest) Validate() error {
+	validationErr := &se.ValidationError{}
+	requireField(validationErr, "token", r.Token)
+	return validationErr.Err()
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+func (r *ForgotPasswordRequest) Validate() error {
+	validationErr := &se.ValidationError{}
+	requireField(validationErr, "email", r.Email)
+	return validationErr.Err()
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (r *ResetPasswordRequest) Validate() error {
+	validationErr := &se.ValidationError{}
+	requireField(validationErr, "token", r.Token)
+	requireField(validationErr, "password", r.Password)
+	return validationErr.Err()
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+func (r *ChangePasswordRequest) Validate() error {
+	validationErr := &se.ValidationError{}
+	requireField(validationErr, "current_password", r.CurrentPassword)
+	requireField(validationErr, "new_password", r.NewPassword)
+	return validationErr.Err()
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (r *RefreshTokenRequest) Validate() error {
+	validationErr := &se.ValidationError{}
+	requireField(validationErr, "refresh_token", r.RefreshToken)
+	return validationErr.Err()
+}
+
+func requireField(validationErr *se.ValidationError, field string, value string) {
+	if value == "" {
+		validationErr.Add(field, "required", "is required")
 	}
-	if r.Password == "" {
-		return fmt.Errorf("password is required")
+}
+
+type TokenResponse struct {
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+func ToTokenResponse(pair sm.TokenPair) TokenResponse {
+	return TokenResponse{
+		Token:            pair.AccessToken,
+		ExpiresAt:        pair.AccessExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
 	}
-	return nil
 }
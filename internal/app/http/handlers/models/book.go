@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
 )
@@ -21,7 +22,11 @@ type BookUpdateRequest struct {
 	Year       int    `json:"year"`
 	Price      int    `json:"price"`
 	CategoryId int    `json:"category_id"`
-	Amount     int    `json:"amount"`
+}
+
+type BookRestockRequest struct {
+	Amount int    `json:"amount"`
+	Reason string `json:"reason"`
 }
 
 func (br *BookCreateRequest) Validate() error {
@@ -37,8 +42,8 @@ func (br *BookCreateRequest) Validate() error {
 	if br.Price == 0 {
 		return fmt.Errorf("price is required")
 	}
-	if br.Amount == 0 {
-		return fmt.Errorf("amount is required")
+	if br.Amount <= 0 {
+		return fmt.Errorf("amount must be positive")
 	}
 	if br.CategoryId == 0 {
 		return fmt.Errorf("category_id is required")
@@ -46,6 +51,16 @@ func (br *BookCreateRequest) Validate() error {
 	return nil
 }
 
+func (br *BookRestockRequest) Validate() error {
+	if br.Amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	if strings.TrimSpace(br.Reason) == "" {
+		return fmt.Errorf("reason is required")
+	}
+	return nil
+}
+
 func (br *BookUpdateRequest) Validate() error {
 	if br.Title == "" {
 		return fmt.Errorf("title is required")
@@ -93,7 +108,6 @@ func ToServiceBookUpdate(request BookUpdateRequest) models.DomainBook {
 		Author:     request.Author,
 		Price:      request.Price,
 		CategoryID: request.CategoryId,
-		Amount:     request.Amount,
 	}
 }
 
@@ -114,10 +128,14 @@ type PaginationResponse struct {
 	Meta  PaginationMeta `json:"meta"`
 }
 
+// PaginationMeta describes a page. Offset pages carry page and total; cursor pages
+// (requested with ?cursor=) carry neither and are navigated through the cursors only.
 type PaginationMeta struct {
-	Page  int `json:"page"`
-	Limit int `json:"limit"`
-	Total int `json:"total"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	Total      *int   `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 func ToBooksResponse(books []models.DomainBook) []BookResponse {
@@ -127,3 +145,36 @@ func ToBooksResponse(books []models.DomainBook) []BookResponse {
 	}
 	return response
 }
+
+type SearchPaginationResponse struct {
+	Books []BookSearchResponse `json:"books"`
+	Meta  PaginationMeta       `json:"meta"`
+}
+
+type BookSearchResponse struct {
+	BookResponse
+	Rank      float64       `json:"rank"`
+	Highlight BookHighlight `json:"highlight"`
+}
+
+// BookHighlight holds the title and the author as safe HTML: they are escaped, and the matched
+// words are wrapped in <mark> tags.
+type BookHighlight struct {
+	Title  string `json:"title"`
+	Author string `json:"author"`
+}
+
+func ToBookSearchResponse(results []models.DomainBookSearchResult) []BookSearchResponse {
+	response := make([]BookSearchResponse, len(results))
+	for i, result := range results {
+		response[i] = BookSearchResponse{
+			BookResponse: ToBookResponse(result.Book),
+			Rank:         result.Rank,
+			Highlight: BookHighlight{
+				Title:  result.TitleHighlight,
+				Author: result.AuthorHighlight,
+			},
+		}
+	}
+	return response
+}
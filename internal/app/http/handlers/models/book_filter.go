@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
+)
+
+var bookSorts = map[string]bool{
+	models.BookSortID:     true,
+	models.BookSortPrice:  true,
+	models.BookSortYear:   true,
+	models.BookSortTitle:  true,
+	models.BookSortNewest: true,
+}
+
+// ToBookFilter reads the listing filters from the query string. Pagination and the admin-only
+// include_sold_out flag are handled by the caller.
+func ToBookFilter(queryParams url.Values) (models.BookFilter, error) {
+	var filter models.BookFilter
+
+	for _, idStr := range queryParams["category_ids"] {
+		for _, id := range strings.Split(idStr, ",") {
+			if strings.TrimSpace(id) == "" {
+				continue
+			}
+			categoryID, err := strconv.Atoi(strings.TrimSpace(id))
+			if err != nil {
+				return models.BookFilter{}, fmt.Errorf("invalid category id %q", id)
+			}
+			filter.CategoryIDs = append(filter.CategoryIDs, categoryID)
+		}
+	}
+
+	filter.Author = strings.TrimSpace(queryParams.Get("author"))
+	filter.Title = strings.TrimSpace(queryParams.Get("title"))
+
+	var err error
+	if filter.YearFrom, err = optionalInt(queryParams, "year_from"); err != nil {
+		return models.BookFilter{}, err
+	}
+	if filter.YearTo, err = optionalInt(queryParams, "year_to"); err != nil {
+		return models.BookFilter{}, err
+	}
+	if filter.PriceFrom, err = optionalInt(queryParams, "price_from"); err != nil {
+		return models.BookFilter{}, err
+	}
+	if filter.PriceTo, err = optionalInt(queryParams, "price_to"); err != nil {
+		return models.BookFilter{}, err
+	}
+
+	if filter.YearFrom != nil && filter.YearTo != nil && *filter.YearFrom > *filter.YearTo {
+		return models.BookFilter{}, fmt.Errorf("year_from must not be greater than year_to")
+	}
+	if filter.PriceFrom != nil && filter.PriceTo != nil && *filter.PriceFrom > *filter.PriceTo {
+		return models.BookFilter{}, fmt.Errorf("price_from must not be greater than price_to")
+	}
+
+	filter.InStockOnly = queryParams.Get("in_stock") == "true"
+
+	filter.SortBy = models.BookSortID
+	if sort := queryParams.Get("sort"); sort != "" {
+		if !bookSorts[sort] {
+			return models.BookFilter{}, fmt.Errorf("unknown sort %q", sort)
+		}
+		filter.SortBy = sort
+	}
+
+	switch queryParams.Get("order") {
+	case "":
+		filter.Descending = filter.SortBy == models.BookSortNewest
+	case "asc":
+		filter.Descending = false
+	case "desc":
+		filter.Descending = true
+	default:
+		return models.BookFilter{}, fmt.Errorf("order must be asc or desc")
+	}
+
+	return filter, nil
+}
+
+func optionalInt(queryParams url.Values, key string) (*int, error) {
+	value := strings.TrimSpace(queryParams.Get(key))
+	if value == "" {
+		return nil, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", key)
+	}
+	return &number, nil
+}
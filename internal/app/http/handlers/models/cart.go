@@ -1,5 +1,79 @@
 package models
 
+import (
+	"fmt"
+	"time"
+
+	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
+)
+
 type CartUpdateRequest struct {
-	BookIds []int `json:"book_ids"`
+	Items []CartItemRequest `json:"items"`
+}
+
+type CartItemRequest struct {
+	BookId   int `json:"book_id"`
+	Quantity int `json:"quantity"`
+}
+
+func (cr *CartUpdateRequest) Validate() error {
+	if len(cr.Items) == 0 {
+		return fmt.Errorf("items are required")
+	}
+
+	seen := make(map[int]bool, len(cr.Items))
+	for _, item := range cr.Items {
+		if item.BookId <= 0 {
+			return fmt.Errorf("book_id is required")
+		}
+		if item.Quantity <= 0 {
+			return fmt.Errorf("quantity must be positive")
+		}
+		if seen[item.BookId] {
+			return fmt.Errorf("book_id %d is duplicated", item.BookId)
+		}
+		seen[item.BookId] = true
+	}
+
+	return nil
+}
+
+func ToServiceCartItems(request CartUpdateRequest) []models.DomainCartItem {
+	items := make([]models.DomainCartItem, len(request.Items))
+	for i, item := range request.Items {
+		items[i] = models.DomainCartItem{
+			BookID:   item.BookId,
+			Quantity: item.Quantity,
+		}
+	}
+	return items
+}
+
+type CartResponse struct {
+	Items      []CartItemResponse `json:"items"`
+	TotalPrice int                `json:"total_price"`
+}
+
+type CartItemResponse struct {
+	Book       BookResponse `json:"book"`
+	Quantity   int          `json:"quantity"`
+	ReservedAt time.Time    `json:"reserved_at"`
+	ExpiresAt  time.Time    `json:"expires_at"`
+}
+
+func ToCartResponse(c models.DomainCart) CartResponse {
+	items := make([]CartItemResponse, len(c.Items))
+	for i, item := range c.Items {
+		items[i] = CartItemResponse{
+			Book:       ToBookResponse(item.Book),
+			Quantity:   item.Quantity,
+			ReservedAt: item.ReservedAt,
+			ExpiresAt:  item.ExpiresAt,
+		}
+	}
+
+	return CartResponse{
+		Items:      items,
+		TotalPrice: c.TotalPrice,
+	}
 }
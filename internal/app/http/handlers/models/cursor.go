@@ -0,0 +1,74 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
+)
+
+// bookCursorToken is the payload of the opaque next_cursor/prev_cursor tokens.
+type bookCursorToken struct {
+	SortBy     string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	Key        string `json:"k,omitempty"`
+	ID         int    `json:"i"`
+	Backward   bool   `json:"b,omitempty"`
+}
+
+func EncodeBookCursor(cursor models.BookCursor) string {
+	payload, _ := json.Marshal(bookCursorToken{
+		SortBy:     cursor.SortBy,
+		Descending: cursor.Descending,
+		Key:        cursor.Key,
+		ID:         cursor.ID,
+		Backward:   cursor.Backward,
+	})
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// DecodeBookCursor parses a cursor token and checks that it was issued for the same ordering as the filter.
+func DecodeBookCursor(token string, filter models.BookFilter) (models.BookCursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return models.BookCursor{}, fmt.Errorf("malformed cursor")
+	}
+
+	var t bookCursorToken
+	if err := json.Unmarshal(payload, &t); err != nil {
+		return models.BookCursor{}, fmt.Errorf("malformed cursor")
+	}
+
+	if t.SortBy != filter.SortBy || t.Descending != filter.Descending {
+		return models.BookCursor{}, fmt.Errorf("cursor does not match sort and order")
+	}
+	if t.ID <= 0 || !validBookCursorKey(t.SortBy, t.Key) {
+		return models.BookCursor{}, fmt.Errorf("malformed cursor")
+	}
+
+	return models.BookCursor{
+		SortBy:     t.SortBy,
+		Descending: t.Descending,
+		Key:        t.Key,
+		ID:         t.ID,
+		Backward:   t.Backward,
+	}, nil
+}
+
+// validBookCursorKey checks that the key can be compared with the column of the sort, so that a
+// tampered cursor is rejected here rather than by the query.
+func validBookCursorKey(sortBy string, key string) bool {
+	switch sortBy {
+	case models.BookSortPrice, models.BookSortYear:
+		_, err := strconv.Atoi(key)
+		return err == nil
+	case models.BookSortNewest:
+		_, err := time.Parse(time.RFC3339Nano, key)
+		return err == nil
+	default:
+		return true
+	}
+}
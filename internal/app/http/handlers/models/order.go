@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
+)
+
+type OrderResponse struct {
+	ID         int                 `json:"id"`
+	TotalPrice int                 `json:"total_price"`
+	CreatedAt  time.Time           `json:"created_at"`
+	Items      []OrderItemResponse `json:"items"`
+}
+
+type OrderItemResponse struct {
+	BookID   *int   `json:"book_id"`
+	Title    string `json:"title"`
+	Author   string `json:"author"`
+	Price    int    `json:"price"`
+	Quantity int    `json:"quantity"`
+}
+
+func ToOrderResponse(o models.DomainOrder) OrderResponse {
+	items := make([]OrderItemResponse, len(o.Items))
+	for i, item := range o.Items {
+		items[i] = OrderItemResponse{
+			BookID:   item.BookID,
+			Title:    item.Title,
+			Author:   item.Author,
+			Price:    item.Price,
+			Quantity: item.Quantity,
+		}
+	}
+
+	return OrderResponse{
+		ID:         o.ID,
+		TotalPrice: o.TotalPrice,
+		CreatedAt:  o.CreatedAt,
+		Items:      items,
+	}
+}
+
+type OrdersPaginationResponse struct {
+	Orders []OrderResponse `json:"orders"`
+	Meta   PaginationMeta  `json:"meta"`
+}
+
+func ToOrdersResponse(orders []models.DomainOrder) []OrderResponse {
+	response := make([]OrderResponse, len(orders))
+	for i, order := range orders {
+		response[i] = ToOrderResponse(order)
+	}
+	return response
+}
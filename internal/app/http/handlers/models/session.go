@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
+)
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+}
+
+type SessionsResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
+
+func ToSessionsResponse(sessions []models.DomainSession) SessionsResponse {
+	response := make([]SessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = SessionResponse{
+			ID:         session.ID,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			UserAgent:  session.Device.UserAgent,
+			IP:         session.Device.IP,
+		}
+	}
+	return SessionsResponse{Sessions: response}
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
+)
+
+type StockMovementResponse struct {
+	ID        int       `json:"id"`
+	BookID    int       `json:"book_id"`
+	Delta     int       `json:"delta"`
+	Reason    string    `json:"reason"`
+	UserID    *int      `json:"user_id"`
+	Note      *string   `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type StockHistoryResponse struct {
+	Movements []StockMovementResponse `json:"movements"`
+	Meta      PaginationMeta          `json:"meta"`
+}
+
+func ToStockMovementsResponse(movements []models.DomainStockMovement) []StockMovementResponse {
+	response := make([]StockMovementResponse, len(movements))
+	for i, m := range movements {
+		response[i] = StockMovementResponse{
+			ID:        m.ID,
+			BookID:    m.BookID,
+			Delta:     m.Delta,
+			Reason:    m.Reason,
+			UserID:    m.UserID,
+			Note:      m.Note,
+			CreatedAt: m.CreatedAt,
+		}
+	}
+	return response
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
+)
+
+type TwoFactorSignInRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
+
+func (r *TwoFactorSignInRequest) Validate() error {
+	validationErr := &se.ValidationError{}
+	requireField(validationErr, "challenge_token", r.ChallengeToken)
+	requireField(validationErr, "code", r.Code)
+	return validationErr.Err()
+}
+
+// TwoFactorCodeRequest carries a code from the authenticator app or, where allowed, a
+// recovery code.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+func (r *TwoFactorCodeRequest) Validate() error {
+	validationErr := &se.ValidationError{}
+	requireField(validationErr, "code", r.Code)
+	return validationErr.Err()
+}
+
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	ChallengeToken    string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+func ToTwoFactorChallengeResponse(challenge sm.TwoFactorChallenge) TwoFactorChallengeResponse {
+	return TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    challenge.Token,
+		ExpiresAt:         challenge.ExpiresAt,
+	}
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+func ToTOTPEnrollmentResponse(enrollment sm.TOTPEnrollment) TOTPEnrollmentResponse {
+	return TOTPEnrollmentResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURI: enrollment.URI,
+	}
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -1,6 +1,11 @@
 package models
 
 import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
 	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
 )
 
@@ -15,3 +20,95 @@ func ToDomainUser(email string, password string) models.DomainUser {
 		Password: password,
 	}
 }
+
+type UserResponse struct {
+	ID              int        `json:"id"`
+	Email           string     `json:"email"`
+	DisplayName     *string    `json:"display_name"`
+	IsAdmin         bool       `json:"is_admin"`
+	DisabledAt      *time.Time `json:"disabled_at"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at"`
+	// Lockout is only filled in when a single user is fetched.
+	Lockout *LockoutResponse `json:"lockout,omitempty"`
+}
+
+// UpdateProfileRequest is a partial update: fields left out are not changed, and an empty
+// display_name clears it. A null display_name is rejected rather than taken as left out.
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name"`
+
+	displayNameNull bool
+}
+
+func (r *UpdateProfileRequest) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if value, ok := fields["display_name"]; ok && bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+		r.displayNameNull = true
+	}
+
+	type request UpdateProfileRequest
+	return json.Unmarshal(data, (*request)(r))
+}
+
+func (r *UpdateProfileRequest) Validate() error {
+	validationErr := &se.ValidationError{}
+	if r.displayNameNull {
+		validationErr.Add("display_name", "null", `can not be null, send "" to clear it`)
+	}
+	return validationErr.Err()
+}
+
+func (r *UpdateProfileRequest) ToProfileUpdate() models.ProfileUpdate {
+	return models.ProfileUpdate{DisplayName: r.DisplayName}
+}
+
+type LockoutResponse struct {
+	FailedAttempts int        `json:"failed_attempts"`
+	LastFailedAt   *time.Time `json:"last_failed_at"`
+	LockedUntil    *time.Time `json:"locked_until"`
+	Locked         bool       `json:"locked"`
+}
+
+type UsersPaginationResponse struct {
+	Users []UserResponse `json:"users"`
+	Meta  PaginationMeta `json:"meta"`
+}
+
+type ForceLogoutResponse struct {
+	SessionsRevoked int `json:"sessions_revoked"`
+}
+
+func ToUserResponse(user models.DomainUser) UserResponse {
+	return UserResponse{
+		ID:              user.Id,
+		Email:           user.Email,
+		DisplayName:     user.DisplayName,
+		IsAdmin:         user.IsAdmin,
+		DisabledAt:      user.DisabledAt,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+}
+
+func ToUsersResponse(users []models.DomainUser) []UserResponse {
+	response := make([]UserResponse, len(users))
+	for i, user := range users {
+		response[i] = ToUserResponse(user)
+	}
+	return response
+}
+
+func ToLockoutResponse(lockout models.DomainLockout) *LockoutResponse {
+	return &LockoutResponse{
+		FailedAttempts: lockout.FailedAttempts,
+		LastFailedAt:   lockout.LastFailedAt,
+		LockedUntil:    lockout.LockedUntil,
+		Locked:         lockout.LockedUntil != nil && lockout.LockedUntil.After(time.Now()),
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	he "github.com/AnatolyGolang/book-shop/internal/app/http/handlers/errors"
+	"github.com/AnatolyGolang/book-shop/internal/app/http/handlers/models"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
+
+	"github.com/gorilla/mux"
+)
+
+func (h HttpServer) GetOrders(w http.ResponseWriter, r *http.Request) {
+	user, err := sm.GetUserFromContext(r.Context())
+	if err != nil {
+		he.Unauthorised("unauthorized", err, w, r)
+		return
+	}
+
+	page, limit := parsePagination(r.URL.Query())
+	offset := (page - 1) * limit
+
+	orders, total, err := h.orderService.GetOrders(r.Context(), user.Id, limit, offset)
+	if err != nil {
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	response := models.OrdersPaginationResponse{
+		Orders: models.ToOrdersResponse(orders),
+		Meta: models.PaginationMeta{
+			Page:  page,
+			Limit: limit,
+			Total: &total,
+		},
+	}
+
+	he.RespondOK(response, w)
+}
+
+func (h HttpServer) GetOrder(w http.ResponseWriter, r *http.Request) {
+	user, err := sm.GetUserFromContext(r.Context())
+	if err != nil {
+		he.Unauthorised("unauthorized", err, w, r)
+		return
+	}
+
+	vars := mux.Vars(r)
+	orderID, err := strconv.Atoi(vars["order_id"])
+	if err != nil {
+		he.BadRequest("invalid-order-id", err, w, r)
+		return
+	}
+
+	order, err := h.orderService.GetOrder(r.Context(), user.Id, orderID)
+	if err != nil {
+		if errors.Is(err, se.ErrNotFound) {
+			he.NotFound("order-not-found", err, w, r)
+			return
+		}
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	he.RespondOK(models.ToOrderResponse(order), w)
+}
+
+func (h HttpServer) GetUserOrders(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID, err := strconv.Atoi(vars["user_id"])
+	if err != nil {
+		he.BadRequest("invalid-user-id", err, w, r)
+		return
+	}
+
+	page, limit := parsePagination(r.URL.Query())
+	offset := (page - 1) * limit
+
+	orders, total, err := h.orderService.GetOrders(r.Context(), userID, limit, offset)
+	if err != nil {
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	response := models.OrdersPaginationResponse{
+		Orders: models.ToOrdersResponse(orders),
+		Meta: models.PaginationMeta{
+			Page:  page,
+			Limit: limit,
+			Total: &total,
+		},
+	}
+
+	he.RespondOK(response, w)
+}
+
+func (h HttpServer) GetAnyOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	orderID, err := strconv.Atoi(vars["order_id"])
+	if err != nil {
+		he.BadRequest("invalid-order-id", err, w, r)
+		return
+	}
+
+	order, err := h.orderService.GetOrderByID(r.Context(), orderID)
+	if err != nil {
+		if errors.Is(err, se.ErrNotFound) {
+			he.NotFound("order-not-found", err, w, r)
+			return
+		}
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	he.RespondOK(models.ToOrderResponse(order), w)
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/url"
+	"strconv"
+)
+
+const (
+	MinLimit = 50
+	MaxLimit = 100
+)
+
+// parsePagination reads page and limit from the query, falling back to the first page and clamping the limit.
+func parsePagination(queryParams url.Values) (int, int) {
+	page, err := strconv.Atoi(queryParams.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit < MinLimit {
+		limit = MinLimit
+	} else if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	return page, limit
+}
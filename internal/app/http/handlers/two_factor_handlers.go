@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	he "github.com/AnatolyGolang/book-shop/internal/app/http/handlers/errors"
+	"github.com/AnatolyGolang/book-shop/internal/app/http/handlers/models"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
+	"github.com/AnatolyGolang/book-shop/internal/app/utils"
+)
+
+func (h HttpServer) SignInTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var signInRequest models.TwoFactorSignInRequest
+	if err := json.NewDecoder(r.Body).Decode(&signInRequest); err != nil {
+		he.BadRequest("invalid-json", err, w, r)
+		return
+	}
+
+	if err := signInRequest.Validate(); err != nil {
+		respondInvalidRequest(err, w, r)
+		return
+	}
+
+	user, err := h.twoFactorService.CompleteChallenge(r.Context(), signInRequest.ChallengeToken, signInRequest.Code,
+		utils.ClientIP(r))
+	if err != nil {
+		respondTwoFactorError(err, w, r)
+		return
+	}
+
+	tokens, err := h.jwtService.IssueTokens(r.Context(), user, sm.NewDevice(r.UserAgent(), utils.ClientIP(r)))
+	if err != nil {
+		he.RespondWithError(err, w, r)
+		return
+	}
+
+	he.RespondOK(models.ToTokenResponse(tokens), w)
+}
+
+func (h HttpServer) BeginTOTPEnrollment(w http.ResponseWriter, r *http.Request) {
+	user, err := sm.GetUserFromContext(r.Context())
+	if err != nil {
+		he.Unauthorised("unauthorized", err, w, r)
+		return
+	}
+
+	enrollment, err := h.twoFactorService.BeginEnrollment(r.Context(), user.Id)
+	if err != nil {
+		respondTwoFactorError(err, w, r)
+		return
+	}
+
+	he.RespondOK(models.ToTOTPEnrollmentResponse(enrollment), w)
+}
+
+// ConfirmTOTPEnrollment turns two-factor authentication on. It also ends every session of the
+// user, including this one, so the client has to sign in again with a code.
+func (h HttpServer) ConfirmTOTPEnrollment(w http.ResponseWriter, r *http.Request) {
+	user, err := sm.GetUserFromContext(r.Context())
+	if err != nil {
+		he.Unauthorised("unauthorized", err, w, r)
+		return
+	}
+
+	var codeRequest models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&codeRequest); err != nil {
+		he.BadRequest("invalid-json", err, w, r)
+		return
+	}
+
+	if err := codeRequest.Validate(); err != nil {
+		respondInvalidRequest(err, w, r)
+		return
+	}
+
+	recoveryCodes, err := h.twoFactorService.ConfirmEnrollment(r.Context(), user.Id, codeRequest.Code)
+	if err != nil {
+		respondTwoFactorError(err, w, r)
+		return
+	}
+
+	he.RespondOK(models.RecoveryCodesResponse{RecoveryCodes: recoveryCodes}, w)
+}
+
+func (h HttpServer) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user, err := sm.GetUserFromContext(r.Context())
+	if err != nil {
+		he.Unauthorised("unauthorized", err, w, r)
+		return
+	}
+
+	var codeRequest models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&codeRequest); err != nil {
+		he.BadRequest("invalid-json", err, w, r)
+		return
+	}
+
+	if err := codeRequest.Validate(); err != nil {
+		respondInvalidRequest(err, w, r)
+		return
+	}
+
+	if err := h.twoFactorService.Disable(r.Context(), user.Id, codeRequest.Code); err != nil {
+		respondTwoFactorError(err, w, r)
+		return
+	}
+
+	he.RespondOK(map[string]bool{"ok": true}, w)
+}
+
+func respondTwoFactorError(err error, w http.ResponseWriter, r *http.Request) {
+	var lockedErr *se.LockedError
+	switch {
+	case errors.As(err, &lockedErr):
+		w.Header().Set("Retry-After", utils.RetryAfterSeconds(time.Until(lockedErr.Until)))
+		he.TooManyRequests("too-many-attempts", err, w, r)
+	case errors.Is(err, se.ErrInvalidTwoFactorChallenge):
+		he.Unauthorised("invalid-two-factor-challenge", err, w, r)
+	case errors.Is(err, se.ErrInvalidTwoFactorCode):
+		he.Unauthorised("invalid-two-factor-code", err, w, r)
+	case errors.Is(err, se.ErrAccountDisabled):
+		he.Unauthorised("account-disabled", err, w, r)
+	case errors.Is(err, se.ErrTwoFactorAlreadyEnabled):
+		he.Conflict("two-factor-already-enabled", err, w, r)
+	case errors.Is(err, se.ErrTwoFactorNotEnabled):
+		he.Conflict("two-factor-not-enabled", err, w, r)
+	case errors.Is(err, se.ErrTwoFactorRequired):
+		he.Conflict("two-factor-required", err, w, r)
+	case errors.Is(err, se.ErrNotFound):
+		he.NotFound("user-not-found", err, w, r)
+	default:
+		he.RespondWithError(err, w, r)
+	}
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	he "github.com/AnatolyGolang/book-shop/internal/app/http/handlers/errors"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+)
+
+// respondInvalidRequest responds with 400, listing the rejected fields when err carries them.
+func respondInvalidRequest(err error, w http.ResponseWriter, r *http.Request) {
+	var validationErr *se.ValidationError
+	if !errors.As(err, &validationErr) {
+		he.BadRequest("invalid-request", err, w, r)
+		return
+	}
+
+	fields := make([]he.FieldError, 0, len(validationErr.Fields))
+	for _, field := range validationErr.Fields {
+		fields = append(fields, he.FieldError{Field: field.Field, Slug: field.Slug, Message: field.Message})
+	}
+	he.InvalidFields(fields, err, w, r)
+}
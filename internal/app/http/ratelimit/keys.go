@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
+	"github.com/AnatolyGolang/book-shop/internal/app/utils"
+)
+
+// KeyFunc identifies the client a request is counted against.
+type KeyFunc func(r *http.Request) string
+
+func ByIP(r *http.Request) string {
+	return "ip:" + utils.ClientIP(r)
+}
+
+// ByUser counts requests per authenticated user, so it has to run after the auth middleware.
+// Anonymous requests are counted per IP.
+func ByUser(r *http.Request) string {
+	user, err := sm.GetUserFromContext(r.Context())
+	if err != nil {
+		return ByIP(r)
+	}
+	return "user:" + strconv.Itoa(user.Id)
+}
+
+// ByAPIKey counts requests per API key sent in header, and per IP when there is none. Clients
+// choose the header value, so use it only behind middleware that rejects unknown keys.
+func ByAPIKey(header string) KeyFunc {
+	return func(r *http.Request) string {
+		key := r.Header.Get(header)
+		if key == "" {
+			return ByIP(r)
+		}
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(sum[:])
+	}
+}
@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimitValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   Limit
+		wantErr bool
+	}{
+		{name: "per minute", limit: PerMinute(10)},
+		{name: "per hour with burst", limit: PerHour(20).WithBurst(5)},
+		{name: "zero requests", limit: PerMinute(0), wantErr: true},
+		{name: "negative requests", limit: PerSecond(-1), wantErr: true},
+		{name: "zero period", limit: Limit{Requests: 1, Burst: 1}, wantErr: true},
+		{name: "zero burst", limit: PerMinute(10).WithBurst(0), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limit.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	type take struct {
+		after          time.Duration
+		wantAllowed    bool
+		wantRemaining  int
+		wantRetryAfter time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		limit Limit
+		takes []take
+	}{
+		{
+			name:  "burst is used up, then refills one token per interval",
+			limit: PerMinute(6).WithBurst(2),
+			takes: []take{
+				{wantAllowed: true, wantRemaining: 1},
+				{wantAllowed: true, wantRemaining: 0},
+				{wantAllowed: false, wantRemaining: 0, wantRetryAfter: 10 * time.Second},
+				{after: 4 * time.Second, wantAllowed: false, wantRemaining: 0, wantRetryAfter: 6 * time.Second},
+				{after: 6 * time.Second, wantAllowed: true, wantRemaining: 0},
+			},
+		},
+		{
+			name:  "refill stops at the burst",
+			limit: PerSecond(1).WithBurst(3),
+			takes: []take{
+				{wantAllowed: true, wantRemaining: 2},
+				{after: time.Hour, wantAllowed: true, wantRemaining: 2},
+			},
+		},
+		{
+			name:  "a burst of one allows a request per interval",
+			limit: PerMinute(1),
+			takes: []take{
+				{wantAllowed: true, wantRemaining: 0},
+				{after: 30 * time.Second, wantAllowed: false, wantRemaining: 0, wantRetryAfter: 30 * time.Second},
+				{after: 30 * time.Second, wantAllowed: true, wantRemaining: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			store := NewMemoryStore()
+			store.now = func() time.Time { return now }
+
+			for i, tk := range tt.takes {
+				now = now.Add(tk.after)
+
+				result, err := store.Take(context.Background(), "key", tt.limit)
+				if err != nil {
+					t.Fatalf("take %d: unexpected error: %v", i, err)
+				}
+				if result.Allowed != tk.wantAllowed {
+					t.Errorf("take %d: Allowed = %v, want %v", i, result.Allowed, tk.wantAllowed)
+				}
+				if result.Remaining != tk.wantRemaining {
+					t.Errorf("take %d: Remaining = %d, want %d", i, result.Remaining, tk.wantRemaining)
+				}
+				if diff := result.RetryAfter - tk.wantRetryAfter; diff < -time.Millisecond || diff > time.Millisecond {
+					t.Errorf("take %d: RetryAfter = %v, want %v", i, result.RetryAfter, tk.wantRetryAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreTakeSeparatesKeys(t *testing.T) {
+	store := NewMemoryStore()
+	limit := PerMinute(1)
+
+	for _, key := range []string{"a", "b"} {
+		result, err := store.Take(context.Background(), key, limit)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.Allowed {
+			t.Errorf("first request of %q was not allowed", key)
+		}
+	}
+}
+
+func TestMemoryStoreTakeRejectsInvalidLimit(t *testing.T) {
+	store := NewMemoryStore()
+
+	if _, err := store.Take(context.Background(), "key", PerMinute(0)); err == nil {
+		t.Fatal("expected an error for a limit that never refills")
+	}
+}
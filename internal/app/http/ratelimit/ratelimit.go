@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	he "github.com/AnatolyGolang/book-shop/internal/app/http/handlers/errors"
+	"github.com/AnatolyGolang/book-shop/internal/app/utils"
+)
+
+// Limit describes a token bucket: it holds up to Burst tokens and refills Requests tokens every Per.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+func PerSecond(requests int) Limit {
+	return Limit{Requests: requests, Per: time.Second, Burst: requests}
+}
+
+func PerMinute(requests int) Limit {
+	return Limit{Requests: requests, Per: time.Minute, Burst: requests}
+}
+
+func PerHour(requests int) Limit {
+	return Limit{Requests: requests, Per: time.Hour, Burst: requests}
+}
+
+func (l Limit) WithBurst(burst int) Limit {
+	l.Burst = burst
+	return l
+}
+
+// Validate rejects limits that never let a request through or never refill.
+func (l Limit) Validate() error {
+	if l.Requests <= 0 || l.Per <= 0 {
+		return fmt.Errorf("rate limit must refill at least one request per period, got %d per %v", l.Requests, l.Per)
+	}
+	if l.Burst <= 0 {
+		return fmt.Errorf("rate limit burst must be positive, got %d", l.Burst)
+	}
+	return nil
+}
+
+// rate returns how many tokens are added per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long to wait before the next request is allowed; zero when Allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store keeps the buckets. Take removes one token from the bucket under key, if there is one.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+type Limiter struct {
+	store Store
+}
+
+func New(store Store) *Limiter {
+	return &Limiter{store: store}
+}
+
+// Limit returns middleware that allows the requests of each client, as told apart by keyFunc,
+// according to limit. name separates the buckets of different routes. It panics if the limit is
+// invalid, as routes are set up once at startup.
+func (l *Limiter) Limit(name string, limit Limit, keyFunc KeyFunc) func(http.HandlerFunc) http.HandlerFunc {
+	if err := limit.Validate(); err != nil {
+		panic(fmt.Sprintf("rate limit %v: %v", name, err))
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			result, err := l.store.Take(r.Context(), name+":"+keyFunc(r), limit)
+			if err != nil {
+				// Failing open keeps the API available when the store is down.
+				log.Printf("rate limit store error: %v", err)
+				next(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", utils.RetryAfterSeconds(result.RetryAfter))
+				he.TooManyRequests("rate-limited", nil, w, r)
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
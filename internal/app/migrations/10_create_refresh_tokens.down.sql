@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS user_tokens_family_id_idx;
+
+ALTER TABLE user_tokens
+    DROP COLUMN IF EXISTS family_id;
+
+DROP table refresh_tokens;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER                                NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family_id  TEXT                                   NOT NULL,
+    token_hash TEXT UNIQUE                            NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE               NOT NULL,
+    rotated_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+ALTER TABLE user_tokens
+    ADD COLUMN IF NOT EXISTS family_id TEXT;
+
+CREATE INDEX IF NOT EXISTS user_tokens_family_id_idx ON user_tokens (family_id);
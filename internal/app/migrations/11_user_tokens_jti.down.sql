@@ -0,0 +1,7 @@
+DELETE FROM user_tokens;
+
+ALTER TABLE user_tokens
+    DROP COLUMN IF EXISTS jti;
+
+ALTER TABLE user_tokens
+    ADD COLUMN IF NOT EXISTS token TEXT UNIQUE NOT NULL;
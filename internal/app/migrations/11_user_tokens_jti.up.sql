@@ -0,0 +1,9 @@
+-- Access tokens are now tracked by their jti claim instead of the whole signed token.
+-- Existing rows cannot be mapped to a jti, so those sessions have to sign in again.
+DELETE FROM user_tokens;
+
+ALTER TABLE user_tokens
+    DROP COLUMN IF EXISTS token;
+
+ALTER TABLE user_tokens
+    ADD COLUMN IF NOT EXISTS jti TEXT UNIQUE NOT NULL;
@@ -0,0 +1,14 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS is_admin BOOLEAN DEFAULT FALSE NOT NULL;
+
+UPDATE users u
+SET is_admin = TRUE
+FROM user_roles ur
+         JOIN roles r ON r.id = ur.role_id
+WHERE ur.user_id = u.id
+  AND r.name = 'admin';
+
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
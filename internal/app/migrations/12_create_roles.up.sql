@@ -0,0 +1,58 @@
+CREATE TABLE IF NOT EXISTS roles
+(
+    id          SERIAL PRIMARY KEY,
+    name        TEXT UNIQUE NOT NULL,
+    description TEXT        NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS permissions
+(
+    id          SERIAL PRIMARY KEY,
+    name        TEXT UNIQUE NOT NULL,
+    description TEXT        NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions
+(
+    role_id       INTEGER NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    permission_id INTEGER NOT NULL REFERENCES permissions (id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles
+(
+    user_id    INTEGER                                NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role_id    INTEGER                                NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL,
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX IF NOT EXISTS user_roles_role_id_idx ON user_roles (role_id);
+
+INSERT INTO roles (name, description)
+VALUES ('admin', 'Full access'),
+       ('catalog_editor', 'Manages books, categories and stock'),
+       ('support', 'Reads customer orders');
+
+INSERT INTO permissions (name, description)
+VALUES ('books:write', 'Create, update, delete and restock books'),
+       ('categories:write', 'Create, update and delete categories'),
+       ('stock:read', 'Read stock history and sold-out books'),
+       ('orders:read', 'Read orders of any user'),
+       ('users:manage', 'Manage user accounts and roles');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+         JOIN permissions p ON r.name = 'admin'
+    OR (r.name = 'catalog_editor' AND p.name IN ('books:write', 'categories:write', 'stock:read'))
+    OR (r.name = 'support' AND p.name = 'orders:read');
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id
+FROM users u
+         JOIN roles r ON r.name = 'admin'
+WHERE u.is_admin;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS is_admin;
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS admin_audit_log_append_only ON admin_audit_log;
+DROP FUNCTION IF EXISTS admin_audit_log_append_only();
+
+DROP TABLE IF EXISTS admin_audit_log;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS disabled_at;
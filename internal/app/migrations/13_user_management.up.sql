@@ -0,0 +1,28 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP WITH TIME ZONE;
+
+-- actor_id and target_user_id are deliberately not foreign keys: the log has to outlive deleted users.
+CREATE TABLE IF NOT EXISTS admin_audit_log
+(
+    id             BIGSERIAL PRIMARY KEY,
+    actor_id       INTEGER,
+    action         TEXT                                   NOT NULL,
+    target_user_id INTEGER,
+    details        JSONB,
+    created_at     TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS admin_audit_log_target_user_id_created_at_idx ON admin_audit_log (target_user_id, created_at DESC);
+
+CREATE OR REPLACE FUNCTION admin_audit_log_append_only() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION 'admin_audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER admin_audit_log_append_only
+    BEFORE UPDATE OR DELETE
+    ON admin_audit_log
+    FOR EACH ROW
+EXECUTE FUNCTION admin_audit_log_append_only();
@@ -0,0 +1,13 @@
+-- Failed sign-in attempts, counted per account (the normalised email, whether or not it is
+-- registered, so that lockouts do not reveal which emails exist) and per client IP.
+CREATE TABLE IF NOT EXISTS login_throttles
+(
+    scope           TEXT                     NOT NULL CHECK (login_throttles.scope IN ('account', 'ip')),
+    key             TEXT                     NOT NULL,
+    failed_attempts INTEGER                  NOT NULL DEFAULT 0,
+    last_failed_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    locked_until    TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS login_throttles_last_failed_at_idx ON login_throttles (last_failed_at);
@@ -0,0 +1,2 @@
+-- The original spelling of the emails is not kept, so only the index can be undone.
+DROP INDEX IF EXISTS users_email_lower_idx;
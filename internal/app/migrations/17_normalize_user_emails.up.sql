@@ -0,0 +1,26 @@
+-- Emails are now stored lower-cased and trimmed, and looked up that way. Accounts whose
+-- canonical emails clash could no longer sign in, so the migration refuses to run until they
+-- have been merged by hand, and lists their ids.
+DO
+$$
+    DECLARE
+        conflicts TEXT;
+    BEGIN
+        SELECT string_agg(ids, '; ')
+        INTO conflicts
+        FROM (SELECT string_agg(id::TEXT, ', ' ORDER BY id) AS ids
+              FROM users
+              GROUP BY lower(trim(email))
+              HAVING count(*) > 1) clashes;
+
+        IF conflicts IS NOT NULL THEN
+            RAISE EXCEPTION 'users with the same canonical email have to be merged first, ids: %', conflicts;
+        END IF;
+    END
+$$;
+
+UPDATE users
+SET email = lower(trim(email))
+WHERE email <> lower(trim(email));
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_idx ON users (lower(email));
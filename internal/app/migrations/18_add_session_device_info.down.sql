@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS user_tokens_user_id_idx;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN IF EXISTS ip,
+    DROP COLUMN IF EXISTS user_agent;
+
+ALTER TABLE user_tokens
+    DROP COLUMN IF EXISTS ip,
+    DROP COLUMN IF EXISTS user_agent;
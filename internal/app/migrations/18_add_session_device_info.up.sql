@@ -0,0 +1,11 @@
+-- Device info is captured at sign-in and carried over to every token the session is refreshed into,
+-- so that users can tell their sessions apart. Sessions started before this migration have none.
+ALTER TABLE user_tokens
+    ADD COLUMN IF NOT EXISTS user_agent TEXT,
+    ADD COLUMN IF NOT EXISTS ip         TEXT;
+
+ALTER TABLE refresh_tokens
+    ADD COLUMN IF NOT EXISTS user_agent TEXT,
+    ADD COLUMN IF NOT EXISTS ip         TEXT;
+
+CREATE INDEX IF NOT EXISTS user_tokens_user_id_idx ON user_tokens (user_id);
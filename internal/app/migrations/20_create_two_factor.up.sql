@@ -0,0 +1,33 @@
+-- The TOTP secret is encrypted with AES-GCM by the application; confirmed_at stays NULL until
+-- the user proves the authenticator app works. last_used_step stops a code from being replayed.
+CREATE TABLE IF NOT EXISTS user_totp
+(
+    user_id        INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret         BYTEA                                  NOT NULL,
+    created_at     TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL,
+    confirmed_at   TIMESTAMP WITH TIME ZONE,
+    last_used_step BIGINT
+);
+
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER                                NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash  TEXT                                   NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL,
+    used_at    TIMESTAMP WITH TIME ZONE,
+    UNIQUE (user_id, code_hash)
+);
+
+-- A challenge is issued when the password of a user with two-factor authentication is right,
+-- and is exchanged for tokens together with a code.
+CREATE TABLE IF NOT EXISTS two_factor_challenges
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER                                NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash TEXT UNIQUE                            NOT NULL,
+    attempts   INTEGER                  DEFAULT 0     NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE               NOT NULL,
+    used_at    TIMESTAMP WITH TIME ZONE
+);
@@ -0,0 +1,3 @@
+-- Sessions are listed from the user's live refresh tokens, one per token family.
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_id_idx ON refresh_tokens (user_id)
+    WHERE rotated_at IS NULL AND revoked_at IS NULL;
@@ -0,0 +1,5 @@
+DELETE FROM login_throttles WHERE scope = 'two_factor';
+
+ALTER TABLE login_throttles
+    DROP CONSTRAINT IF EXISTS login_throttles_scope_check,
+    ADD CONSTRAINT login_throttles_scope_check CHECK (scope IN ('account', 'ip'));
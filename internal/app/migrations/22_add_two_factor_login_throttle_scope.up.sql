@@ -0,0 +1,6 @@
+-- Wrong two-factor codes are counted per account in their own scope. Unlike the password
+-- counter, it is not reset by a correct password, so getting new challenges does not allow
+-- guessing codes without end.
+ALTER TABLE login_throttles
+    DROP CONSTRAINT IF EXISTS login_throttles_scope_check,
+    ADD CONSTRAINT login_throttles_scope_check CHECK (scope IN ('account', 'ip', 'two_factor'));
@@ -0,0 +1,2 @@
+DROP table order_items;
+DROP table orders;
\ No newline at end of file
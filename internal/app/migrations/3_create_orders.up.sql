@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS orders
+(
+    id          SERIAL PRIMARY KEY,
+    user_id     INTEGER                                NOT NULL,
+    total_price INT                                    NOT NULL CHECK (orders.total_price >= 0),
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL,
+
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS orders_user_id_created_at_idx ON orders (user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS order_items
+(
+    id       SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    book_id  INTEGER REFERENCES books (id) ON DELETE SET NULL,
+    title    TEXT    NOT NULL,
+    author   TEXT    NOT NULL,
+    price    INT     NOT NULL CHECK (order_items.price >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS order_items_order_id_idx ON order_items (order_id);
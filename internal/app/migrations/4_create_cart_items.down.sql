@@ -0,0 +1,19 @@
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS quantity;
+
+CREATE TABLE IF NOT EXISTS carts
+(
+    user_id    INTEGER                                NOT NULL PRIMARY KEY,
+    book_ids   INTEGER[]                              NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE,
+
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+INSERT INTO carts (user_id, book_ids, updated_at)
+SELECT user_id, array_agg(book_id ORDER BY book_id), MAX(reserved_at)
+FROM cart_items
+GROUP BY user_id;
+
+DROP TABLE cart_items;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS cart_items
+(
+    user_id     INTEGER                                NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    book_id     INTEGER                                NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    quantity    INT                                    NOT NULL CHECK (cart_items.quantity > 0),
+    reserved_at TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL,
+
+    PRIMARY KEY (user_id, book_id)
+);
+
+CREATE INDEX IF NOT EXISTS cart_items_reserved_at_idx ON cart_items (reserved_at);
+
+INSERT INTO cart_items (user_id, book_id, quantity, reserved_at)
+SELECT carts.user_id, cart_book.book_id, COUNT(*), COALESCE(carts.updated_at, carts.created_at)
+FROM carts,
+     unnest(carts.book_ids) AS cart_book(book_id)
+WHERE EXISTS (SELECT 1 FROM books WHERE books.id = cart_book.book_id)
+GROUP BY carts.user_id, cart_book.book_id, COALESCE(carts.updated_at, carts.created_at);
+
+DROP TABLE IF EXISTS carts;
+
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS quantity INT DEFAULT 1 NOT NULL CHECK (order_items.quantity > 0);
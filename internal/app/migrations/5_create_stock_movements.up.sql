@@ -0,0 +1,32 @@
+-- book_id and user_id are deliberately not foreign keys: the ledger has to outlive deleted books and users.
+CREATE TABLE IF NOT EXISTS stock_movements
+(
+    id         BIGSERIAL PRIMARY KEY,
+    book_id    INTEGER                                NOT NULL,
+    delta      INT                                    NOT NULL,
+    reason     TEXT                                   NOT NULL
+        CHECK (stock_movements.reason IN ('reserve', 'release', 'sale', 'restock', 'adjustment')),
+    user_id    INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS stock_movements_book_id_created_at_idx ON stock_movements (book_id, created_at DESC);
+
+CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stock_movements_append_only
+    BEFORE UPDATE OR DELETE
+    ON stock_movements
+    FOR EACH ROW
+EXECUTE FUNCTION stock_movements_append_only();
+
+-- Opening balance, so that the ledger of every existing book adds up to its current amount.
+INSERT INTO stock_movements (book_id, delta, reason)
+SELECT id, amount, 'adjustment'
+FROM books
+WHERE amount > 0;
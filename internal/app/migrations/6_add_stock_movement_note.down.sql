@@ -0,0 +1,2 @@
+ALTER TABLE stock_movements
+    DROP COLUMN IF EXISTS note;
@@ -0,0 +1,2 @@
+ALTER TABLE stock_movements
+    ADD COLUMN IF NOT EXISTS note TEXT;
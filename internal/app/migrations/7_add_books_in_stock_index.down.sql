@@ -0,0 +1 @@
+DROP INDEX IF EXISTS books_in_stock_category_id_idx;
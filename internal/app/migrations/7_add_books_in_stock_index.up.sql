@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS books_in_stock_category_id_idx ON books (category_id, id) WHERE amount > 0;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS books_search_vector_idx;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS books_price_id_idx;
+DROP INDEX IF EXISTS books_year_id_idx;
+DROP INDEX IF EXISTS books_title_id_idx;
+DROP INDEX IF EXISTS books_created_at_id_idx;
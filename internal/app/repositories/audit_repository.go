@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	rm "github.com/AnatolyGolang/book-shop/internal/app/repositories/models"
+	"github.com/AnatolyGolang/book-shop/internal/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type AuditRepositoryImpl struct {
+	db *postgres.DBConnection
+}
+
+func NewAuditRepository(db *postgres.DBConnection) *AuditRepositoryImpl {
+	return &AuditRepositoryImpl{db: db}
+}
+
+func (r *AuditRepositoryImpl) GetAuditLog(ctx context.Context, targetUserID int, limit int, offset int) ([]rm.AuditEntry, int, error) {
+	query := `SELECT id, actor_id, action, target_user_id, details, created_at
+        	  FROM admin_audit_log
+        	  WHERE target_user_id = $1
+              ORDER BY created_at DESC, id DESC
+              LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(ctx, query, targetUserID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []rm.AuditEntry
+	for rows.Next() {
+		var entry rm.AuditEntry
+		err := rows.Scan(&entry.ID, &entry.ActorID, &entry.Action, &entry.TargetUserID, &entry.Details, &entry.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating audit log: %w", err)
+	}
+
+	countQuery := `SELECT COUNT(*) FROM admin_audit_log WHERE target_user_id = $1`
+	var total int
+	err = r.db.QueryRow(ctx, countQuery, targetUserID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+// recordAudit appends an entry inside the transaction that performs the audited change,
+// so an action is never committed without its audit record.
+func recordAudit(ctx context.Context, tx pgx.Tx, actorID int, action string, targetUserID int, details map[string]any) error {
+	query := `INSERT INTO admin_audit_log (actor_id, action, target_user_id, details)
+              VALUES ($1, $2, $3, $4)`
+
+	_, err := tx.Exec(ctx, query, actorID, action, targetUserID, details)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
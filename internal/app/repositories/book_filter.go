@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
+)
+
+// bookSortColumns whitelists the columns a listing may be ordered by; user input never reaches the SQL text.
+var bookSortColumns = map[string]string{
+	sm.BookSortID:     "id",
+	sm.BookSortPrice:  "price",
+	sm.BookSortYear:   "year",
+	sm.BookSortTitle:  "title",
+	sm.BookSortNewest: "created_at",
+}
+
+// bookFilterQuery accumulates WHERE conditions with positional arguments.
+type bookFilterQuery struct {
+	conditions []string
+	args       []any
+}
+
+func (q *bookFilterQuery) add(condition string, arg any) {
+	q.args = append(q.args, arg)
+	q.conditions = append(q.conditions, fmt.Sprintf(condition, len(q.args)))
+}
+
+func (q *bookFilterQuery) where() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(q.conditions, " AND ")
+}
+
+func newBookFilterQuery(filter sm.BookFilter) *bookFilterQuery {
+	q := &bookFilterQuery{}
+
+	if len(filter.CategoryIDs) > 0 {
+		q.add("category_id = ANY($%d)", filter.CategoryIDs)
+	}
+	if filter.Author != "" {
+		q.add(`author ILIKE '%%' || $%d || '%%'`, escapeLike(filter.Author))
+	}
+	if filter.Title != "" {
+		q.add(`title ILIKE '%%' || $%d || '%%'`, escapeLike(filter.Title))
+	}
+	if filter.YearFrom != nil {
+		q.add("year >= $%d", *filter.YearFrom)
+	}
+	if filter.YearTo != nil {
+		q.add("year <= $%d", *filter.YearTo)
+	}
+	if filter.PriceFrom != nil {
+		q.add("price >= $%d", *filter.PriceFrom)
+	}
+	if filter.PriceTo != nil {
+		q.add("price <= $%d", *filter.PriceTo)
+	}
+	if filter.InStockOnly || !filter.IncludeSoldOut {
+		q.conditions = append(q.conditions, "amount > 0")
+	}
+
+	return q
+}
+
+// addCursor restricts the query to the rows after the cursor in the direction it points to.
+func (q *bookFilterQuery) addCursor(cursor sm.BookCursor) error {
+	operator := ">"
+	if cursor.Descending != cursor.Backward {
+		operator = "<"
+	}
+
+	column, ok := bookSortColumns[cursor.SortBy]
+	if !ok {
+		return fmt.Errorf("unknown sort %q", cursor.SortBy)
+	}
+
+	if column == "id" {
+		q.add("id "+operator+" $%d", cursor.ID)
+		return nil
+	}
+
+	var key any
+	switch cursor.SortBy {
+	case sm.BookSortPrice, sm.BookSortYear:
+		number, err := strconv.Atoi(cursor.Key)
+		if err != nil {
+			return fmt.Errorf("invalid cursor key: %w", err)
+		}
+		key = number
+	case sm.BookSortNewest:
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return fmt.Errorf("invalid cursor key: %w", err)
+		}
+		key = createdAt
+	default:
+		key = cursor.Key
+	}
+
+	q.args = append(q.args, key, cursor.ID)
+	q.conditions = append(q.conditions,
+		fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, operator, len(q.args)-1, len(q.args)))
+	return nil
+}
+
+func bookOrderBy(filter sm.BookFilter) string {
+	column, ok := bookSortColumns[filter.SortBy]
+	if !ok {
+		column = bookSortColumns[sm.BookSortID]
+	}
+
+	direction := "ASC"
+	if filter.Descending {
+		direction = "DESC"
+	}
+
+	if column == "id" {
+		return "ORDER BY id " + direction
+	}
+	return fmt.Sprintf("ORDER BY %s %s, id %s", column, direction, direction)
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
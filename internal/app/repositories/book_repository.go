@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	rm "github.com/AnatolyGolang/book-shop/internal/app/repositories/models"
 	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
@@ -21,13 +22,19 @@ func NewBookRepository(db *postgres.DBConnection) *BookRepositoryImpl {
 	return &BookRepositoryImpl{db: db}
 }
 
-func (r *BookRepositoryImpl) CreateBook(ctx context.Context, book sm.DomainBook) (rm.Book, error) {
+func (r *BookRepositoryImpl) CreateBook(ctx context.Context, book sm.DomainBook, actorID *int) (rm.Book, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return rm.Book{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
 	query := `INSERT INTO books (title, author, category_id, price, amount, year) 
-              VALUES ($1, $2, $3, $4, 10, $5) 
+              VALUES ($1, $2, $3, $4, $5, $6) 
               RETURNING id, title, author, category_id, price, amount, year, created_at, updated_at`
 
 	var newBook rm.Book
-	err := r.db.QueryRow(ctx, query, book.Title, book.Author, book.CategoryID, book.Price, book.Year).Scan(
+	err = tx.QueryRow(ctx, query, book.Title, book.Author, book.CategoryID, book.Price, book.Amount, book.Year).Scan(
 		&newBook.ID, &newBook.Title, &newBook.Author, &newBook.CategoryID,
 		&newBook.Price, &newBook.Amount, &newBook.Year, &newBook.CreatedAt, &newBook.UpdatedAt,
 	)
@@ -35,6 +42,17 @@ func (r *BookRepositoryImpl) CreateBook(ctx context.Context, book sm.DomainBook)
 		return rm.Book{}, fmt.Errorf("failed to create book: %w", err)
 	}
 
+	if newBook.Amount != 0 {
+		err = recordStockMovement(ctx, tx, newBook.ID, newBook.Amount, rm.StockReasonRestock, actorID, "")
+		if err != nil {
+			return rm.Book{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return rm.Book{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return newBook, nil
 }
 
@@ -63,13 +81,15 @@ func (r *BookRepositoryImpl) GetBook(ctx context.Context, id int) (rm.Book, erro
 	return book, nil
 }
 
+// UpdateBook edits the catalog data of a book. Stock is immutable here and only changes through
+// reservations and RestockBook, so every change of amount ends up in the ledger.
 func (r *BookRepositoryImpl) UpdateBook(ctx context.Context, id int, book sm.DomainBook) (rm.Book, error) {
-	query := `UPDATE books SET title = $1, author = $2, category_id = $3, price = $4, amount = $5, year = $6, updated_at = NOW()
-              WHERE id = $7 
+	query := `UPDATE books SET title = $1, author = $2, category_id = $3, price = $4, year = $5, updated_at = NOW()
+              WHERE id = $6 
               RETURNING id, title, author, category_id, price, amount, year, created_at, updated_at`
 
 	var updatedBook rm.Book
-	err := r.db.QueryRow(ctx, query, book.Title, book.Author, book.CategoryID, book.Price, book.Amount, book.Year, id).Scan(
+	err := r.db.QueryRow(ctx, query, book.Title, book.Author, book.CategoryID, book.Price, book.Year, id).Scan(
 		&updatedBook.ID, &updatedBook.Title, &updatedBook.Author, &updatedBook.CategoryID,
 		&updatedBook.Price, &updatedBook.Amount, &updatedBook.Year, &updatedBook.CreatedAt, &updatedBook.UpdatedAt,
 	)
@@ -83,6 +103,45 @@ func (r *BookRepositoryImpl) UpdateBook(ctx context.Context, id int, book sm.Dom
 	return updatedBook, nil
 }
 
+func (r *BookRepositoryImpl) RestockBook(ctx context.Context, id int, amount int, note string, actorID *int) (rm.Book, error) {
+	if amount <= 0 {
+		return rm.Book{}, fmt.Errorf("restock amount must be positive")
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return rm.Book{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	query := `UPDATE books SET amount = amount + $1, updated_at = NOW()
+              WHERE id = $2 
+              RETURNING id, title, author, category_id, price, amount, year, created_at, updated_at`
+
+	var book rm.Book
+	err = tx.QueryRow(ctx, query, amount, id).Scan(
+		&book.ID, &book.Title, &book.Author, &book.CategoryID,
+		&book.Price, &book.Amount, &book.Year, &book.CreatedAt, &book.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return rm.Book{}, se.ErrNotFound
+		}
+		return rm.Book{}, fmt.Errorf("failed to restock book: %w", err)
+	}
+
+	err = recordStockMovement(ctx, tx, book.ID, amount, rm.StockReasonRestock, actorID, note)
+	if err != nil {
+		return rm.Book{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return rm.Book{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return book, nil
+}
+
 func (r *BookRepositoryImpl) DeleteBook(ctx context.Context, id int) error {
 	if id == 0 {
 		return fmt.Errorf("id can not be 0")
@@ -102,18 +161,17 @@ func (r *BookRepositoryImpl) DeleteBook(ctx context.Context, id int) error {
 	return nil
 }
 
-func (r *BookRepositoryImpl) GetBooksByCategories(ctx context.Context, categoryIDs []int, limit int, offset int) ([]rm.Book, int, error) {
-	if len(categoryIDs) == 0 {
-		return nil, 0, fmt.Errorf("categoryIDs cannot be empty")
-	}
+// GetBooks lists the books matching the filter together with the total number of matches.
+func (r *BookRepositoryImpl) GetBooks(ctx context.Context, filter sm.BookFilter) ([]rm.Book, int, error) {
+	q := newBookFilterQuery(filter)
 
-	query := `SELECT id, title, author, category_id, price, amount, year, created_at, updated_at 
+	query := fmt.Sprintf(`SELECT id, title, author, category_id, price, amount, year, created_at, updated_at 
         	  FROM books 
-        	  WHERE category_id = ANY($1) 
-              ORDER BY id 
-              LIMIT $2 OFFSET $3`
+        	  %s 
+              %s 
+              LIMIT $%d OFFSET $%d`, q.where(), bookOrderBy(filter), len(q.args)+1, len(q.args)+2)
 
-	rows, err := r.db.Query(ctx, query, categoryIDs, limit, offset)
+	rows, err := r.db.Query(ctx, query, append(q.args, filter.Limit, filter.Offset)...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get books: %w", err)
 	}
@@ -136,9 +194,9 @@ func (r *BookRepositoryImpl) GetBooksByCategories(ctx context.Context, categoryI
 		return nil, 0, fmt.Errorf("error iterating books: %w", err)
 	}
 
-	countQuery := `SELECT COUNT(*) FROM books WHERE category_id = ANY($1)`
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM books %s`, q.where())
 	var total int
-	err = r.db.QueryRow(ctx, countQuery, categoryIDs).Scan(&total)
+	err = r.db.QueryRow(ctx, countQuery, q.args...).Scan(&total)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, 0, nil
@@ -148,3 +206,148 @@ func (r *BookRepositoryImpl) GetBooksByCategories(ctx context.Context, categoryI
 
 	return books, total, nil
 }
+
+// GetBooksByCursor returns up to filter.Limit books next to the cursor using keyset pagination,
+// and whether there are more books beyond them in the same direction. Books are always returned
+// in the listing order, also when paging backward.
+func (r *BookRepositoryImpl) GetBooksByCursor(ctx context.Context, filter sm.BookFilter, cursor sm.BookCursor) ([]rm.Book, bool, error) {
+	q := newBookFilterQuery(filter)
+	if err := q.addCursor(cursor); err != nil {
+		return nil, false, err
+	}
+
+	order := filter
+	order.Descending = filter.Descending != cursor.Backward
+
+	query := fmt.Sprintf(`SELECT id, title, author, category_id, price, amount, year, created_at, updated_at 
+        	  FROM books 
+        	  %s 
+              %s 
+              LIMIT $%d`, q.where(), bookOrderBy(order), len(q.args)+1)
+
+	rows, err := r.db.Query(ctx, query, append(q.args, filter.Limit+1)...)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get books: %w", err)
+	}
+	defer rows.Close()
+
+	var books []rm.Book
+	for rows.Next() {
+		var book rm.Book
+		err := rows.Scan(
+			&book.ID, &book.Title, &book.Author, &book.CategoryID,
+			&book.Price, &book.Amount, &book.Year, &book.CreatedAt, &book.UpdatedAt,
+		)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to scan book: %w", err)
+		}
+		books = append(books, book)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("error iterating books: %w", err)
+	}
+
+	hasMore := len(books) > filter.Limit
+	if hasMore {
+		books = books[:filter.Limit]
+	}
+
+	if cursor.Backward {
+		slices.Reverse(books)
+	}
+
+	return books, hasMore, nil
+}
+
+// SearchBooks runs a full-text search over titles and authors, best matches first. The filter on
+// search_vector is served by its GIN index; headlines are only built for the rows of the page.
+// Headlines are safe HTML: the text is escaped before the matches are wrapped in <mark> tags.
+func (r *BookRepositoryImpl) SearchBooks(ctx context.Context, text string, includeSoldOut bool, limit int, offset int) ([]rm.BookSearchResult, int, error) {
+	query := fmt.Sprintf(`SELECT id, title, author, category_id, price, amount, year, created_at, updated_at, rank,
+                     ts_headline('simple', %s, query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true'),
+                     ts_headline('simple', %s, query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')
+              FROM (SELECT books.*, query, ts_rank(search_vector, query) AS rank
+                    FROM books, websearch_to_tsquery('simple', $1) AS query
+                    WHERE search_vector @@ query AND ($2 OR amount > 0)
+                    ORDER BY rank DESC, id
+                    LIMIT $3 OFFSET $4) AS matches
+              ORDER BY rank DESC, id`, escapeHTML("title"), escapeHTML("author"))
+
+	rows, err := r.db.Query(ctx, query, text, includeSoldOut, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search books: %w", err)
+	}
+	defer rows.Close()
+
+	var results []rm.BookSearchResult
+	for rows.Next() {
+		var result rm.BookSearchResult
+		err := rows.Scan(
+			&result.ID, &result.Title, &result.Author, &result.CategoryID,
+			&result.Price, &result.Amount, &result.Year, &result.CreatedAt, &result.UpdatedAt,
+			&result.Rank, &result.TitleHighlight, &result.AuthorHighlight,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan book: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating books: %w", err)
+	}
+
+	countQuery := `SELECT COUNT(*) FROM books 
+                   WHERE search_vector @@ websearch_to_tsquery('simple', $1) AND ($2 OR amount > 0)`
+	var total int
+	err = r.db.QueryRow(ctx, countQuery, text, includeSoldOut).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count books: %w", err)
+	}
+
+	return results, total, nil
+}
+
+func (r *BookRepositoryImpl) GetBooksByIDs(ctx context.Context, ids []int) ([]rm.Book, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := `SELECT id, title, author, category_id, price, amount, year, created_at, updated_at 
+        	  FROM books 
+        	  WHERE id = ANY($1) 
+              ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get books: %w", err)
+	}
+	defer rows.Close()
+
+	var books []rm.Book
+	for rows.Next() {
+		var book rm.Book
+		err := rows.Scan(
+			&book.ID, &book.Title, &book.Author, &book.CategoryID,
+			&book.Price, &book.Amount, &book.Year, &book.CreatedAt, &book.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan book: %w", err)
+		}
+		books = append(books, book)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating books: %w", err)
+	}
+
+	return books, nil
+}
+
+// escapeHTML returns an SQL expression that HTML-escapes the text column. The text search parser
+// keeps the resulting entities as they are, so headlines built from it stay escaped.
+func escapeHTML(column string) string {
+	return fmt.Sprintf(`replace(replace(replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`,
+		column)
+}
@@ -2,13 +2,16 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
-	"github.com/AnatolyGolang/book-shop/internal/app/logger"
+	rm "github.com/AnatolyGolang/book-shop/internal/app/repositories/models"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
 	"github.com/AnatolyGolang/book-shop/internal/pkg/postgres"
 
 	"github.com/jackc/pgx/v5"
-	"go.uber.org/zap"
 )
 
 type CartRepositoryImpl struct {
@@ -19,19 +22,21 @@ func NewCartRepository(db *postgres.DBConnection) *CartRepositoryImpl {
 	return &CartRepositoryImpl{db: db}
 }
 
-func (r *CartRepositoryImpl) UpdateCart(ctx context.Context, userID int, bookIds []int) error {
+// UpdateCart reserves the requested number of copies of every book and adds them to the cart.
+// Stock is taken per unit, so adding a book that is already in the cart only reserves the extra copies.
+func (r *CartRepositoryImpl) UpdateCart(ctx context.Context, userID int, items []sm.DomainCartItem) error {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to start transaction: %w", err)
 	}
-	defer func(tx pgx.Tx, ctx context.Context) {
-		err := tx.Rollback(ctx)
-		if err != nil {
-			logger.Logger.Error("failed to rollback", zap.Error(err))
-		}
-	}(tx, ctx)
+	defer rollback(ctx, tx)
+
+	bookIds := make([]int, len(items))
+	for i, item := range items {
+		bookIds[i] = item.BookID
+	}
 
-	query := `SELECT id, amount FROM books WHERE id = ANY($1) FOR UPDATE`
+	query := `SELECT id, amount FROM books WHERE id = ANY($1) ORDER BY id FOR UPDATE`
 	rows, err := tx.Query(ctx, query, bookIds)
 	if err != nil {
 		return fmt.Errorf("failed to lock amounts: %w", err)
@@ -47,28 +52,112 @@ func (r *CartRepositoryImpl) UpdateCart(ctx context.Context, userID int, bookIds
 		amountMap[bookID] = amount
 	}
 
-	for _, bookID := range bookIds {
-		if amountMap[bookID] == 0 {
-			return fmt.Errorf("book out of stock %d", bookID)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating amounts: %w", err)
+	}
+
+	for _, item := range items {
+		amount, ok := amountMap[item.BookID]
+		if !ok {
+			return se.ErrNotFound
+		}
+		if amount < item.Quantity {
+			return se.NewOutOfStockError(item.BookID)
 		}
 	}
 
-	query = `UPDATE books SET amount = amount - 1, updated_at = NOW() WHERE id = ANY($1)`
-	_, err = tx.Exec(ctx, query, bookIds)
+	for _, item := range items {
+		query = `UPDATE books SET amount = amount - $2, updated_at = NOW() WHERE id = $1`
+		_, err = tx.Exec(ctx, query, item.BookID, item.Quantity)
+		if err != nil {
+			return fmt.Errorf("failed to reduce amount: %w", err)
+		}
+
+		err = recordStockMovement(ctx, tx, item.BookID, -item.Quantity, rm.StockReasonReserve, &userID, "")
+		if err != nil {
+			return err
+		}
+
+		query = `
+        INSERT INTO cart_items (user_id, book_id, quantity, reserved_at)
+        VALUES ($1, $2, $3, NOW())
+        ON CONFLICT (user_id, book_id)
+        DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity, reserved_at = NOW()`
+		_, err = tx.Exec(ctx, query, userID, item.BookID, item.Quantity)
+		if err != nil {
+			return fmt.Errorf("failed to update cart: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CartRepositoryImpl) GetCart(ctx context.Context, userID int) ([]rm.CartItem, error) {
+	query := `SELECT user_id, book_id, quantity, reserved_at
+        	  FROM cart_items
+        	  WHERE user_id = $1
+              ORDER BY book_id`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+	defer rows.Close()
+
+	var items []rm.CartItem
+	for rows.Next() {
+		var item rm.CartItem
+		if err := rows.Scan(&item.UserID, &item.BookID, &item.Quantity, &item.ReservedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan cart item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cart items: %w", err)
+	}
+
+	return items, nil
+}
+
+// RemoveFromCart drops the book from the cart and puts all of its reserved copies back on sale.
+// The book is locked before the cart item, like everywhere else the two are locked together.
+func (r *CartRepositoryImpl) RemoveFromCart(ctx context.Context, userID int, bookID int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	query := `SELECT id FROM books WHERE id = $1 FOR UPDATE`
+	_, err = tx.Exec(ctx, query, bookID)
+	if err != nil {
+		return fmt.Errorf("failed to lock amount: %w", err)
+	}
+
+	var quantity int
+	query = `DELETE FROM cart_items WHERE user_id = $1 AND book_id = $2 RETURNING quantity`
+	err = tx.QueryRow(ctx, query, userID, bookID).Scan(&quantity)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return se.ErrNotFound
+		}
+		return fmt.Errorf("failed to remove book from cart: %w", err)
+	}
+
+	query = `UPDATE books SET amount = amount + $2, updated_at = NOW() WHERE id = $1`
+	_, err = tx.Exec(ctx, query, bookID, quantity)
 	if err != nil {
-		return fmt.Errorf("failed to reduce amount: %w", err)
+		return fmt.Errorf("failed to restore amount: %w", err)
 	}
 
-	query = `
-        INSERT INTO carts (user_id, book_ids, updated_at)
-        VALUES ($1, $2, NOW())
-        ON CONFLICT (user_id)
-        DO UPDATE SET book_ids = ARRAY(
-            SELECT DISTINCT unnest(carts.book_ids || EXCLUDED.book_ids)
-        ), updated_at = NOW()`
-	_, err = tx.Exec(ctx, query, userID, bookIds)
+	err = recordStockMovement(ctx, tx, bookID, quantity, rm.StockReasonRelease, &userID, "")
 	if err != nil {
-		return fmt.Errorf("failed to update cart: %w", err)
+		return err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -78,19 +167,168 @@ func (r *CartRepositoryImpl) UpdateCart(ctx context.Context, userID int, bookIds
 	return nil
 }
 
-func (r *CartRepositoryImpl) CleanupExpiredCartItems(ctx context.Context) error {
-	query := `
-        UPDATE carts
-        SET book_ids = ARRAY(
-            SELECT unnest(book_ids)
-            FROM carts
-            WHERE updated_at > NOW() - INTERVAL '30 minutes'
-        ), updated_at = NOW()
-        WHERE updated_at <= NOW() - INTERVAL '30 minutes'`
+// CleanupExpiredCartItems releases every reservation older than ttl and puts exactly those
+// copies back on sale. The age is measured against the database clock, which also set
+// reserved_at. Books are locked before cart items, in the same order UpdateCart and Checkout
+// use, so a cleanup never deadlocks with a user reserving or buying the same book.
+func (r *CartRepositoryImpl) CleanupExpiredCartItems(ctx context.Context, ttl time.Duration) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	var bookIds []int
+	query := `SELECT COALESCE(array_agg(DISTINCT book_id), '{}')
+              FROM cart_items
+              WHERE reserved_at <= NOW() - make_interval(secs => $1)`
+	err = tx.QueryRow(ctx, query, ttl.Seconds()).Scan(&bookIds)
+	if err != nil {
+		return fmt.Errorf("failed to find expired cart items: %w", err)
+	}
+
+	if len(bookIds) == 0 {
+		return nil
+	}
+
+	query = `SELECT id FROM books WHERE id = ANY($1) ORDER BY id FOR UPDATE`
+	_, err = tx.Exec(ctx, query, bookIds)
+	if err != nil {
+		return fmt.Errorf("failed to lock amounts: %w", err)
+	}
 
-	_, err := r.db.Exec(ctx, query)
+	query = `DELETE FROM cart_items
+              WHERE book_id = ANY($1) AND reserved_at <= NOW() - make_interval(secs => $2)
+              RETURNING book_id, quantity`
+	rows, err := tx.Query(ctx, query, bookIds, ttl.Seconds())
 	if err != nil {
 		return fmt.Errorf("failed to remove expired cart items: %w", err)
 	}
+	defer rows.Close()
+
+	released := make(map[int]int)
+	for rows.Next() {
+		var bookID, quantity int
+		if err := rows.Scan(&bookID, &quantity); err != nil {
+			return fmt.Errorf("failed to scan expired cart item: %w", err)
+		}
+		released[bookID] += quantity
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating expired cart items: %w", err)
+	}
+
+	query = `UPDATE books SET amount = amount + $2, updated_at = NOW() WHERE id = $1`
+	for bookID, quantity := range released {
+		_, err = tx.Exec(ctx, query, bookID, quantity)
+		if err != nil {
+			return fmt.Errorf("failed to restore amount: %w", err)
+		}
+
+		err = recordStockMovement(ctx, tx, bookID, quantity, rm.StockReasonRelease, nil, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
+
+// Checkout turns the user's cart into an order. Copies were already taken from stock when
+// they were added to the cart, so only the cart items are consumed here. The books are locked
+// first and the cart items after them, the same order UpdateCart and CleanupExpiredCartItems
+// take, so a checkout and a cleanup of the same items wait for each other instead of deadlocking.
+func (r *CartRepositoryImpl) Checkout(ctx context.Context, userID int) (rm.Order, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return rm.Order{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	query := `SELECT id FROM books
+              WHERE id IN (SELECT book_id FROM cart_items WHERE user_id = $1)
+              ORDER BY id
+              FOR SHARE`
+	_, err = tx.Exec(ctx, query, userID)
+	if err != nil {
+		return rm.Order{}, fmt.Errorf("failed to lock books: %w", err)
+	}
+
+	query = `SELECT cart_items.book_id, cart_items.quantity, books.title, books.author, books.price
+        	  FROM cart_items
+        	  JOIN books ON books.id = cart_items.book_id
+        	  WHERE cart_items.user_id = $1
+              ORDER BY cart_items.book_id
+              FOR UPDATE OF cart_items`
+	rows, err := tx.Query(ctx, query, userID)
+	if err != nil {
+		return rm.Order{}, fmt.Errorf("failed to get cart items: %w", err)
+	}
+	defer rows.Close()
+
+	var order rm.Order
+	for rows.Next() {
+		var item rm.OrderItem
+		var bookID int
+		if err := rows.Scan(&bookID, &item.Quantity, &item.Title, &item.Author, &item.Price); err != nil {
+			return rm.Order{}, fmt.Errorf("failed to scan cart item: %w", err)
+		}
+		item.BookID = &bookID
+		order.TotalPrice += item.Price * item.Quantity
+		order.Items = append(order.Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return rm.Order{}, fmt.Errorf("error iterating cart items: %w", err)
+	}
+
+	if len(order.Items) == 0 {
+		return rm.Order{}, se.ErrEmptyCart
+	}
+
+	query = `INSERT INTO orders (user_id, total_price)
+              VALUES ($1, $2)
+              RETURNING id, user_id, total_price, created_at`
+	err = tx.QueryRow(ctx, query, userID, order.TotalPrice).Scan(
+		&order.ID, &order.UserID, &order.TotalPrice, &order.CreatedAt,
+	)
+	if err != nil {
+		return rm.Order{}, fmt.Errorf("failed to create order: %w", err)
+	}
+
+	query = `INSERT INTO order_items (order_id, book_id, title, author, price, quantity)
+              VALUES ($1, $2, $3, $4, $5, $6)
+              RETURNING id`
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.OrderID = order.ID
+		err = tx.QueryRow(ctx, query, order.ID, item.BookID, item.Title, item.Author, item.Price, item.Quantity).Scan(&item.ID)
+		if err != nil {
+			return rm.Order{}, fmt.Errorf("failed to create order item: %w", err)
+		}
+
+		// The copies left stock when they were reserved, so the sale entry only records what became of them.
+		note := fmt.Sprintf("order %d, %d copies", order.ID, item.Quantity)
+		err = recordStockMovement(ctx, tx, *item.BookID, 0, rm.StockReasonSale, &userID, note)
+		if err != nil {
+			return rm.Order{}, err
+		}
+	}
+
+	query = `DELETE FROM cart_items WHERE user_id = $1`
+	_, err = tx.Exec(ctx, query, userID)
+	if err != nil {
+		return rm.Order{}, fmt.Errorf("failed to clear cart: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return rm.Order{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return order, nil
+}
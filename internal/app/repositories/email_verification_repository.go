@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AnatolyGolang/book-shop/internal/pkg/postgres"
+)
+
+type EmailVerificationRepositoryImpl struct {
+	db *postgres.DBConnection
+}
+
+func NewEmailVerificationRepository(db *postgres.DBConnection) *EmailVerificationRepositoryImpl {
+	return &EmailVerificationRepositoryImpl{db: db}
+}
+
+// CreateVerificationToken replaces the user's verification token, so that only the latest one
+// mailed works.
+func (r *EmailVerificationRepositoryImpl) CreateVerificationToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	if err := verificationTokens.replace(ctx, tx, userID, tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("failed to create verification token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail uses up the token and marks the email of its user as verified.
+func (r *EmailVerificationRepositoryImpl) VerifyEmail(ctx context.Context, tokenHash string) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	userID, err := verificationTokens.consume(ctx, tx, tokenHash)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `UPDATE users
+             SET email_verified_at = COALESCE(email_verified_at, now()),
+                 updated_at        = now()
+             WHERE id = $1`
+	_, err = tx.Exec(ctx, query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return userID, nil
+}
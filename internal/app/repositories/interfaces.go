@@ -10,10 +10,14 @@ import (
 
 type BookRepository interface {
 	GetBook(ctx context.Context, id int) (models.Book, error)
-	CreateBook(ctx context.Context, book domain.DomainBook) (models.Book, error)
+	CreateBook(ctx context.Context, book domain.DomainBook, actorID *int) (models.Book, error)
 	UpdateBook(ctx context.Context, id int, book domain.DomainBook) (models.Book, error)
+	RestockBook(ctx context.Context, id int, amount int, note string, actorID *int) (models.Book, error)
 	DeleteBook(ctx context.Context, id int) error
-	GetBooksByCategories(ctx context.Context, categoryIDs []int, limit int, offset int) ([]models.Book, int, error)
+	GetBooks(ctx context.Context, filter domain.BookFilter) ([]models.Book, int, error)
+	GetBooksByCursor(ctx context.Context, filter domain.BookFilter, cursor domain.BookCursor) ([]models.Book, bool, error)
+	SearchBooks(ctx context.Context, text string, includeSoldOut bool, limit int, offset int) ([]models.BookSearchResult, int, error)
+	GetBooksByIDs(ctx context.Context, ids []int) ([]models.Book, error)
 }
 
 type CategoryRepository interface {
@@ -28,15 +32,83 @@ type UserRepository interface {
 	GetUserByEmail(ctx context.Context, email string) (models.User, error)
 	CreateUser(ctx context.Context, user domain.DomainUser) (models.User, error)
 	GetUserById(ctx context.Context, id int) (models.User, error)
+	GetUsers(ctx context.Context, search string, limit int, offset int) ([]models.User, int, error)
+	SetAdmin(ctx context.Context, actorID int, userID int, isAdmin bool) (models.User, error)
+	SetDisabled(ctx context.Context, actorID int, userID int, disabled bool) (models.User, []string, error)
+	SetDisplayName(ctx context.Context, userID int, displayName *string) (models.User, error)
+	ForceLogout(ctx context.Context, actorID int, userID int) ([]string, error)
+	ChangePassword(ctx context.Context, userID int, passwordHash string) ([]string, error)
+	RehashPassword(ctx context.Context, userID int, oldHash string, newHash string) error
 }
 
 type CartRepository interface {
-	CleanupExpiredCartItems(ctx context.Context) error
-	UpdateCart(ctx context.Context, userID int, bookIds []int) error
+	CleanupExpiredCartItems(ctx context.Context, ttl time.Duration) error
+	UpdateCart(ctx context.Context, userID int, items []domain.DomainCartItem) error
+	Checkout(ctx context.Context, userID int) (models.Order, error)
+	GetCart(ctx context.Context, userID int) ([]models.CartItem, error)
+	RemoveFromCart(ctx context.Context, userID int, bookID int) error
+}
+
+type OrderRepository interface {
+	GetOrders(ctx context.Context, userID int, limit int, offset int) ([]models.Order, int, error)
+	GetOrder(ctx context.Context, userID int, orderID int) (models.Order, error)
+	GetOrderByID(ctx context.Context, orderID int) (models.Order, error)
+}
+
+type StockMovementRepository interface {
+	GetStockMovements(ctx context.Context, bookID int, limit int, offset int) ([]models.StockMovement, int, error)
+}
+
+type LoginThrottleRepository interface {
+	GetLockedUntil(ctx context.Context, account string, ip string) (*time.Time, error)
+	RecordFailure(ctx context.Context, account string, ip string, policy domain.LockoutPolicy) error
+	RecordTwoFactorFailure(ctx context.Context, account string, ip string, policy domain.LockoutPolicy) error
+	ResetAccount(ctx context.Context, account string) error
+	ResetTwoFactor(ctx context.Context, account string) error
+	GetAccountThrottle(ctx context.Context, account string) (models.LoginThrottle, error)
+	UnlockAccount(ctx context.Context, actorID int, userID int, account string) error
+	CleanupStaleThrottles(ctx context.Context, cutoff time.Time) error
+}
+
+type EmailVerificationRepository interface {
+	CreateVerificationToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	VerifyEmail(ctx context.Context, tokenHash string) (int, error)
+}
+
+type PasswordResetRepository interface {
+	CreateResetToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (int, []string, error)
+}
+
+type AuditRepository interface {
+	GetAuditLog(ctx context.Context, targetUserID int, limit int, offset int) ([]models.AuditEntry, int, error)
+}
+
+type RoleRepository interface {
+	GetUserPermissions(ctx context.Context, userID int) ([]string, error)
 }
 
 type TokenRepository interface {
-	SaveToken(ctx context.Context, userId int, token string, expiresAt time.Time) error
-	DeleteToken(ctx context.Context, token string) error
+	SaveToken(ctx context.Context, userId int, jti string, familyID string, expiresAt time.Time, device domain.Device) error
+	RevokeSession(ctx context.Context, jti string) ([]string, error)
+	IsActive(ctx context.Context, jti string) (bool, error)
+	SaveRefreshToken(ctx context.Context, userId int, familyID string, tokenHash string, expiresAt time.Time, device domain.Device) error
+	RotateRefreshToken(ctx context.Context, tokenHash string, newTokenHash string, expiresAt time.Time) (int, string, domain.Device, []string, error)
+	GetSessions(ctx context.Context, userID int) ([]models.Session, error)
+	RevokeUserSession(ctx context.Context, userID int, sessionID string) ([]string, error)
 	CleanupExpiredTokens(ctx context.Context) error
 }
+
+type TwoFactorRepository interface {
+	SaveTOTPSecret(ctx context.Context, userID int, secret []byte) error
+	GetTOTP(ctx context.Context, userID int) (models.TOTP, error)
+	UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error)
+	ConfirmTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID int) error
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	GetTwoFactorState(ctx context.Context, userID int) (models.TwoFactorState, error)
+	CreateChallenge(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	AttemptChallenge(ctx context.Context, tokenHash string, maxAttempts int) (int, int, error)
+	CompleteChallenge(ctx context.Context, challengeID int) error
+	CleanupExpiredChallenges(ctx context.Context) error
+}
@@ -0,0 +1,202 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	rm "github.com/AnatolyGolang/book-shop/internal/app/repositories/models"
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
+	"github.com/AnatolyGolang/book-shop/internal/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type LoginThrottleRepositoryImpl struct {
+	db *postgres.DBConnection
+}
+
+func NewLoginThrottleRepository(db *postgres.DBConnection) *LoginThrottleRepositoryImpl {
+	return &LoginThrottleRepositoryImpl{db: db}
+}
+
+// GetLockedUntil returns the latest lockout that still applies to the account or the IP, if any.
+// An account is locked by wrong passwords as well as by wrong two-factor codes.
+func (r *LoginThrottleRepositoryImpl) GetLockedUntil(ctx context.Context, account string, ip string) (*time.Time, error) {
+	query := `SELECT MAX(locked_until)
+              FROM login_throttles
+              WHERE ((scope IN ($1, $2) AND key = $3) OR (scope = $4 AND key = $5))
+                AND locked_until > now()`
+
+	var lockedUntil *time.Time
+	err := r.db.QueryRow(ctx, query, rm.LoginThrottleScopeAccount, rm.LoginThrottleScopeTwoFactor, account,
+		rm.LoginThrottleScopeIP, ip).Scan(&lockedUntil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lockout: %w", err)
+	}
+	return lockedUntil, nil
+}
+
+// RecordFailure counts a failed sign-in against both the account and the IP and locks either
+// of them that has reached its limit.
+func (r *LoginThrottleRepositoryImpl) RecordFailure(ctx context.Context, account string, ip string, policy sm.LockoutPolicy) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	err = recordThrottleFailure(ctx, tx, rm.LoginThrottleScopeAccount, account, policy, policy.MaxAccountAttempts)
+	if err != nil {
+		return err
+	}
+
+	err = recordThrottleFailure(ctx, tx, rm.LoginThrottleScopeIP, ip, policy, policy.MaxIPAttempts)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RecordTwoFactorFailure counts a wrong two-factor code against the account and the IP and
+// locks either of them that has reached its limit. The account counter is kept apart from the
+// password one, since a correct password resets that.
+func (r *LoginThrottleRepositoryImpl) RecordTwoFactorFailure(ctx context.Context, account string, ip string, policy sm.LockoutPolicy) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	err = recordThrottleFailure(ctx, tx, rm.LoginThrottleScopeTwoFactor, account, policy, policy.MaxAccountAttempts)
+	if err != nil {
+		return err
+	}
+
+	err = recordThrottleFailure(ctx, tx, rm.LoginThrottleScopeIP, ip, policy, policy.MaxIPAttempts)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ResetTwoFactor clears the wrong two-factor codes of the account once it has signed in with a
+// correct one.
+func (r *LoginThrottleRepositoryImpl) ResetTwoFactor(ctx context.Context, account string) error {
+	query := `DELETE FROM login_throttles WHERE scope = $1 AND key = $2`
+
+	_, err := r.db.Exec(ctx, query, rm.LoginThrottleScopeTwoFactor, account)
+	if err != nil {
+		return fmt.Errorf("failed to reset two-factor failures: %w", err)
+	}
+	return nil
+}
+
+// ResetAccount clears the failures of the account after a successful sign-in. The IP counter
+// is left alone, so that one valid account can not be used to keep resetting it.
+func (r *LoginThrottleRepositoryImpl) ResetAccount(ctx context.Context, account string) error {
+	query := `DELETE FROM login_throttles WHERE scope = $1 AND key = $2`
+
+	_, err := r.db.Exec(ctx, query, rm.LoginThrottleScopeAccount, account)
+	if err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+	return nil
+}
+
+// GetAccountThrottle returns the password or the two-factor counter of the account, whichever
+// locks it the longest.
+func (r *LoginThrottleRepositoryImpl) GetAccountThrottle(ctx context.Context, account string) (rm.LoginThrottle, error) {
+	query := `SELECT scope, key, failed_attempts, last_failed_at, locked_until
+              FROM login_throttles
+              WHERE scope IN ($1, $2) AND key = $3
+              ORDER BY locked_until DESC NULLS LAST, failed_attempts DESC
+              LIMIT 1`
+
+	var throttle rm.LoginThrottle
+	err := r.db.QueryRow(ctx, query, rm.LoginThrottleScopeAccount, rm.LoginThrottleScopeTwoFactor, account).Scan(
+		&throttle.Scope, &throttle.Key, &throttle.FailedAttempts, &throttle.LastFailedAt, &throttle.LockedUntil,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return rm.LoginThrottle{Scope: rm.LoginThrottleScopeAccount, Key: account}, nil
+		}
+		return rm.LoginThrottle{}, fmt.Errorf("failed to get login failures: %w", err)
+	}
+	return throttle, nil
+}
+
+// UnlockAccount lifts the lockout of a user's account on behalf of an admin.
+func (r *LoginThrottleRepositoryImpl) UnlockAccount(ctx context.Context, actorID int, userID int, account string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	query := `DELETE FROM login_throttles WHERE scope IN ($1, $2) AND key = $3`
+	tag, err := tx.Exec(ctx, query, rm.LoginThrottleScopeAccount, rm.LoginThrottleScopeTwoFactor, account)
+	if err != nil {
+		return fmt.Errorf("failed to unlock account: %w", err)
+	}
+
+	err = recordAudit(ctx, tx, actorID, rm.AuditActionUnlockUser, userID, map[string]any{"changed": tag.RowsAffected() > 0})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *LoginThrottleRepositoryImpl) CleanupStaleThrottles(ctx context.Context, cutoff time.Time) error {
+	query := `DELETE FROM login_throttles
+              WHERE last_failed_at < $1 AND (locked_until IS NULL OR locked_until < now())`
+
+	_, err := r.db.Exec(ctx, query, cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup login failures: %w", err)
+	}
+	return nil
+}
+
+func recordThrottleFailure(ctx context.Context, tx pgx.Tx, scope string, key string, policy sm.LockoutPolicy, maxAttempts int) error {
+	query := `INSERT INTO login_throttles (scope, key, failed_attempts, last_failed_at)
+              VALUES ($1, $2, 1, now())
+              ON CONFLICT (scope, key) DO UPDATE
+              SET failed_attempts = CASE
+                      WHEN login_throttles.last_failed_at < now() - $3::interval THEN 1
+                      ELSE login_throttles.failed_attempts + 1
+                  END,
+                  last_failed_at  = now()
+              RETURNING failed_attempts`
+
+	var failures int
+	err := tx.QueryRow(ctx, query, scope, key, policy.MaxLockout).Scan(&failures)
+	if err != nil {
+		return fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	lockout := policy.LockoutFor(failures, maxAttempts)
+	if lockout == 0 {
+		return nil
+	}
+
+	query = `UPDATE login_throttles SET locked_until = now() + $3::interval WHERE scope = $1 AND key = $2`
+	_, err = tx.Exec(ctx, query, scope, key, lockout)
+	if err != nil {
+		return fmt.Errorf("failed to lock %v: %w", scope, err)
+	}
+	return nil
+}
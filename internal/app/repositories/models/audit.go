@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	AuditActionGrantAdmin  = "grant_admin"
+	AuditActionRevokeAdmin = "revoke_admin"
+	AuditActionDisableUser = "disable_user"
+	AuditActionEnableUser  = "enable_user"
+	AuditActionForceLogout = "force_logout"
+	AuditActionUnlockUser  = "unlock_user"
+)
+
+type AuditEntry struct {
+	ID           int
+	ActorID      *int
+	Action       string
+	TargetUserID *int
+	Details      map[string]any
+	CreatedAt    time.Time
+}
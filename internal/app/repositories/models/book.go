@@ -15,3 +15,10 @@ type Book struct {
 	CreatedAt  time.Time
 	UpdatedAt  *time.Time
 }
+
+type BookSearchResult struct {
+	Book
+	Rank            float64
+	TitleHighlight  string
+	AuthorHighlight string
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type CartItem struct {
+	UserID     int
+	BookID     int
+	Quantity   int
+	ReservedAt time.Time
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+const (
+	LoginThrottleScopeAccount   = "account"
+	LoginThrottleScopeIP        = "ip"
+	LoginThrottleScopeTwoFactor = "two_factor"
+)
+
+type LoginThrottle struct {
+	Scope          string
+	Key            string
+	FailedAttempts int
+	LastFailedAt   time.Time
+	LockedUntil    *time.Time
+}
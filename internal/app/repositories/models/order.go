@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type Order struct {
+	ID         int
+	UserID     int
+	TotalPrice int
+	CreatedAt  time.Time
+	Items      []OrderItem
+}
+
+type OrderItem struct {
+	ID       int
+	OrderID  int
+	BookID   *int
+	Title    string
+	Author   string
+	Price    int
+	Quantity int
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Session is a refresh token family. ID is the family id, CreatedAt is when the family was
+// started and LastUsedAt is when it was last refreshed.
+type Session struct {
+	ID         string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+	UserAgent  string
+	IP         string
+}
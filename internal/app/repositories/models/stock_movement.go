@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	StockReasonReserve    = "reserve"
+	StockReasonRelease    = "release"
+	StockReasonSale       = "sale"
+	StockReasonRestock    = "restock"
+	StockReasonAdjustment = "adjustment"
+)
+
+type StockMovement struct {
+	ID        int
+	BookID    int
+	Delta     int
+	Reason    string
+	UserID    *int
+	Note      *string
+	CreatedAt time.Time
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type TOTP struct {
+	UserID       int
+	Secret       []byte
+	CreatedAt    time.Time
+	ConfirmedAt  *time.Time
+	LastUsedStep *int64
+}
+
+// TwoFactorState tells whether the user must use two-factor authentication and whether they do.
+type TwoFactorState struct {
+	IsAdmin bool
+	Enabled bool
+}
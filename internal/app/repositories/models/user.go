@@ -3,10 +3,13 @@ package models
 import "time"
 
 type User struct {
-	Id        int
-	Email     string
-	Password  string
-	IsAdmin   bool
-	CreatedAt time.Time
-	UpdatedAt *time.Time
+	Id              int
+	Email           string
+	Password        string
+	DisplayName     *string
+	IsAdmin         bool
+	DisabledAt      *time.Time
+	EmailVerifiedAt *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       *time.Time
 }
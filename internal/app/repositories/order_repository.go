@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	rm "github.com/AnatolyGolang/book-shop/internal/app/repositories/models"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	"github.com/AnatolyGolang/book-shop/internal/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type OrderRepositoryImpl struct {
+	db *postgres.DBConnection
+}
+
+func NewOrderRepository(db *postgres.DBConnection) *OrderRepositoryImpl {
+	return &OrderRepositoryImpl{db: db}
+}
+
+func (r *OrderRepositoryImpl) GetOrders(ctx context.Context, userID int, limit int, offset int) ([]rm.Order, int, error) {
+	query := `SELECT id, user_id, total_price, created_at
+        	  FROM orders
+        	  WHERE user_id = $1
+              ORDER BY created_at DESC, id DESC
+              LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []rm.Order
+	for rows.Next() {
+		var order rm.Order
+		err := rows.Scan(&order.ID, &order.UserID, &order.TotalPrice, &order.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating orders: %w", err)
+	}
+
+	if err := r.loadItems(ctx, orders); err != nil {
+		return nil, 0, err
+	}
+
+	countQuery := `SELECT COUNT(*) FROM orders WHERE user_id = $1`
+	var total int
+	err = r.db.QueryRow(ctx, countQuery, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	return orders, total, nil
+}
+
+// GetOrder returns the order only if it belongs to userID; someone else's order is reported as not found.
+func (r *OrderRepositoryImpl) GetOrder(ctx context.Context, userID int, orderID int) (rm.Order, error) {
+	if orderID == 0 {
+		return rm.Order{}, fmt.Errorf("id can not be 0")
+	}
+
+	query := `SELECT id, user_id, total_price, created_at
+              	FROM orders
+              WHERE id = $1 AND user_id = $2`
+
+	return r.getOrder(ctx, query, orderID, userID)
+}
+
+// GetOrderByID returns the order regardless of its owner, for staff reading customer orders.
+func (r *OrderRepositoryImpl) GetOrderByID(ctx context.Context, orderID int) (rm.Order, error) {
+	if orderID == 0 {
+		return rm.Order{}, fmt.Errorf("id can not be 0")
+	}
+
+	query := `SELECT id, user_id, total_price, created_at
+              	FROM orders
+              WHERE id = $1`
+
+	return r.getOrder(ctx, query, orderID)
+}
+
+func (r *OrderRepositoryImpl) getOrder(ctx context.Context, query string, args ...any) (rm.Order, error) {
+	var order rm.Order
+	err := r.db.QueryRow(ctx, query, args...).Scan(
+		&order.ID, &order.UserID, &order.TotalPrice, &order.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return rm.Order{}, se.ErrNotFound
+		}
+		return rm.Order{}, fmt.Errorf("failed to get an order: %w", err)
+	}
+
+	orders := []rm.Order{order}
+	if err := r.loadItems(ctx, orders); err != nil {
+		return rm.Order{}, err
+	}
+
+	return orders[0], nil
+}
+
+func (r *OrderRepositoryImpl) loadItems(ctx context.Context, orders []rm.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	orderIDs := make([]int, len(orders))
+	positions := make(map[int]int, len(orders))
+	for i, order := range orders {
+		orderIDs[i] = order.ID
+		positions[order.ID] = i
+	}
+
+	query := `SELECT id, order_id, book_id, title, author, price, quantity
+        	  FROM order_items
+        	  WHERE order_id = ANY($1)
+              ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, orderIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get order items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item rm.OrderItem
+		err := rows.Scan(&item.ID, &item.OrderID, &item.BookID, &item.Title, &item.Author, &item.Price, &item.Quantity)
+		if err != nil {
+			return fmt.Errorf("failed to scan order item: %w", err)
+		}
+		i := positions[item.OrderID]
+		orders[i].Items = append(orders[i].Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating order items: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AnatolyGolang/book-shop/internal/pkg/postgres"
+)
+
+type PasswordResetRepositoryImpl struct {
+	db *postgres.DBConnection
+}
+
+func NewPasswordResetRepository(db *postgres.DBConnection) *PasswordResetRepositoryImpl {
+	return &PasswordResetRepositoryImpl{db: db}
+}
+
+// CreateResetToken replaces the user's reset token, so that only the latest one mailed works.
+func (r *PasswordResetRepositoryImpl) CreateResetToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	if err := passwordResetTokens.replace(ctx, tx, userID, tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword uses up the token, sets the new password of its user and ends all of their
+// sessions. It returns the user and the jtis of the revoked access tokens.
+func (r *PasswordResetRepositoryImpl) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (int, []string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	userID, err := passwordResetTokens.consume(ctx, tx, tokenHash)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	revoked, err := setPassword(ctx, tx, userID, passwordHash)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return userID, revoked, nil
+}
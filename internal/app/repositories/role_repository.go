@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AnatolyGolang/book-shop/internal/pkg/postgres"
+)
+
+type RoleRepositoryImpl struct {
+	db *postgres.DBConnection
+}
+
+func NewRoleRepository(db *postgres.DBConnection) *RoleRepositoryImpl {
+	return &RoleRepositoryImpl{db: db}
+}
+
+func (r *RoleRepositoryImpl) GetUserPermissions(ctx context.Context, userID int) ([]string, error) {
+	query := `SELECT DISTINCT p.name
+        	  FROM user_roles ur
+        	  JOIN role_permissions rp ON rp.role_id = ur.role_id
+        	  JOIN permissions p ON p.id = rp.permission_id
+        	  WHERE ur.user_id = $1
+              ORDER BY p.name`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permissions: %w", err)
+	}
+	defer rows.Close()
+
+	var permissions []string
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, fmt.Errorf("failed to scan permission: %w", err)
+		}
+		permissions = append(permissions, permission)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating permissions: %w", err)
+	}
+
+	return permissions, nil
+}
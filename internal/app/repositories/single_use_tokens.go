@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// singleUseTokens works with a table of hashed tokens that are mailed to a user and can be used
+// once, like email verification and password reset tokens. The table must have the columns
+// id, user_id, token_hash, expires_at and used_at. invalid is returned for a token that is
+// unknown, expired or already used.
+type singleUseTokens struct {
+	table   string
+	invalid error
+}
+
+var (
+	verificationTokens  = singleUseTokens{table: "email_verification_tokens", invalid: se.ErrInvalidVerificationToken}
+	passwordResetTokens = singleUseTokens{table: "password_reset_tokens", invalid: se.ErrInvalidResetToken}
+)
+
+// replace stores a new token for the user and drops the earlier ones, so that only the latest
+// email sent can be used.
+func (t singleUseTokens) replace(ctx context.Context, tx pgx.Tx, userID int, tokenHash string, expiresAt time.Time) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1`, t.table)
+	_, err := tx.Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tokens: %w", err)
+	}
+
+	query = fmt.Sprintf(`INSERT INTO %s (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`, t.table)
+	_, err = tx.Exec(ctx, query, userID, tokenHash, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+	return nil
+}
+
+// consume marks the token as used and returns its user. The row stays locked until tx ends,
+// so the same token can not be used twice concurrently.
+func (t singleUseTokens) consume(ctx context.Context, tx pgx.Tx, tokenHash string) (int, error) {
+	var (
+		id, userID int
+		expiresAt  time.Time
+		usedAt     *time.Time
+	)
+	query := fmt.Sprintf(`SELECT id, user_id, expires_at, used_at
+              FROM %s
+              WHERE token_hash = $1
+              FOR UPDATE`, t.table)
+	err := tx.QueryRow(ctx, query, tokenHash).Scan(&id, &userID, &expiresAt, &usedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, t.invalid
+		}
+		return 0, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	if usedAt != nil || expiresAt.Before(time.Now()) {
+		return 0, t.invalid
+	}
+
+	query = fmt.Sprintf(`UPDATE %s SET used_at = now() WHERE id = $1`, t.table)
+	_, err = tx.Exec(ctx, query, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to use token: %w", err)
+	}
+	return userID, nil
+}
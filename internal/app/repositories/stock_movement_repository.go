@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	rm "github.com/AnatolyGolang/book-shop/internal/app/repositories/models"
+	"github.com/AnatolyGolang/book-shop/internal/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type StockMovementRepositoryImpl struct {
+	db *postgres.DBConnection
+}
+
+func NewStockMovementRepository(db *postgres.DBConnection) *StockMovementRepositoryImpl {
+	return &StockMovementRepositoryImpl{db: db}
+}
+
+func (r *StockMovementRepositoryImpl) GetStockMovements(ctx context.Context, bookID int, limit int, offset int) ([]rm.StockMovement, int, error) {
+	query := `SELECT id, book_id, delta, reason, user_id, note, created_at
+        	  FROM stock_movements
+        	  WHERE book_id = $1
+              ORDER BY created_at DESC, id DESC
+              LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(ctx, query, bookID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get stock movements: %w", err)
+	}
+	defer rows.Close()
+
+	var movements []rm.StockMovement
+	for rows.Next() {
+		var movement rm.StockMovement
+		err := rows.Scan(
+			&movement.ID, &movement.BookID, &movement.Delta, &movement.Reason, &movement.UserID, &movement.Note, &movement.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan stock movement: %w", err)
+		}
+		movements = append(movements, movement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating stock movements: %w", err)
+	}
+
+	countQuery := `SELECT COUNT(*) FROM stock_movements WHERE book_id = $1`
+	var total int
+	err = r.db.QueryRow(ctx, countQuery, bookID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count stock movements: %w", err)
+	}
+
+	return movements, total, nil
+}
+
+// recordStockMovement appends a ledger entry inside the transaction that changes books.amount,
+// so a stock change and its explanation are committed or rolled back together.
+func recordStockMovement(ctx context.Context, tx pgx.Tx, bookID int, delta int, reason string, userID *int, note string) error {
+	query := `INSERT INTO stock_movements (book_id, delta, reason, user_id, note)
+              VALUES ($1, $2, $3, $4, NULLIF($5, ''))`
+
+	_, err := tx.Exec(ctx, query, bookID, delta, reason, userID, note)
+	if err != nil {
+		return fmt.Errorf("failed to record stock movement: %w", err)
+	}
+	return nil
+}
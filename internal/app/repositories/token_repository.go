@@ -2,10 +2,16 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	rm "github.com/AnatolyGolang/book-shop/internal/app/repositories/models"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
 	"github.com/AnatolyGolang/book-shop/internal/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
 )
 
 type TokenRepositoryImpl struct {
@@ -16,34 +22,267 @@ func NewTokenRepository(db *postgres.DBConnection) *TokenRepositoryImpl {
 	return &TokenRepositoryImpl{db: db}
 }
 
-func (r *TokenRepositoryImpl) SaveToken(ctx context.Context, userId int, token string, expiresAt time.Time) error {
-	query := `INSERT INTO user_tokens(user_id, token, expires_at)
-			  VALUES($1, $2, $3)`
+func (r *TokenRepositoryImpl) SaveToken(ctx context.Context, userId int, jti string, familyID string, expiresAt time.Time, device sm.Device) error {
+	query := `INSERT INTO user_tokens(user_id, jti, family_id, expires_at, user_agent, ip)
+			  VALUES($1, $2, $3, $4, $5, $6)`
 
-	_, err := r.db.Exec(ctx, query, userId, token, expiresAt)
+	_, err := r.db.Exec(ctx, query, userId, jti, familyID, expiresAt, device.UserAgent, device.IP)
 	if err != nil {
 		return fmt.Errorf("failed to save token: %w", err)
 	}
 	return nil
 }
 
-func (r *TokenRepositoryImpl) DeleteToken(ctx context.Context, token string) error {
-	query := `DELETE FROM user_tokens WHERE token = $1`
-	result, err := r.db.Exec(ctx, query, token)
+// RevokeSession ends the session the access token belongs to: every access token of its
+// family is deleted and the family's refresh tokens can no longer be used. The jtis of the
+// other access tokens of the family are returned.
+func (r *TokenRepositoryImpl) RevokeSession(ctx context.Context, jti string) ([]string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	var familyID *string
+	query := `DELETE FROM user_tokens WHERE jti = $1 RETURNING family_id`
+	err = tx.QueryRow(ctx, query, jti).Scan(&familyID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("token not found")
+		}
+		return nil, fmt.Errorf("failed to delete token: %w", err)
+	}
+
+	var revoked []string
+	if familyID != nil {
+		revoked, err = revokeFamily(ctx, tx, *familyID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return revoked, nil
+}
+
+func (r *TokenRepositoryImpl) IsActive(ctx context.Context, jti string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM user_tokens WHERE jti = $1 AND expires_at > now())`
+
+	var active bool
+	err := r.db.QueryRow(ctx, query, jti).Scan(&active)
 	if err != nil {
-		return fmt.Errorf("failed to delete token: %w", err)
+		return false, fmt.Errorf("failed to check token: %w", err)
 	}
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("token not found")
+	return active, nil
+}
+
+func (r *TokenRepositoryImpl) SaveRefreshToken(ctx context.Context, userId int, familyID string, tokenHash string, expiresAt time.Time, device sm.Device) error {
+	query := `INSERT INTO refresh_tokens(user_id, family_id, token_hash, expires_at, user_agent, ip)
+			  VALUES($1, $2, $3, $4, $5, $6)`
+
+	_, err := r.db.Exec(ctx, query, userId, familyID, tokenHash, expiresAt, device.UserAgent, device.IP)
+	if err != nil {
+		return fmt.Errorf("failed to save refresh token: %w", err)
 	}
 	return nil
 }
 
+// RotateRefreshToken exchanges a refresh token for a new one of the same family and returns the
+// owner, the family and the device the session was started from. Presenting a token that has
+// already been rotated means it leaked, so the whole family is revoked and ErrRefreshTokenReused
+// is returned together with the jtis of the family's revoked access tokens.
+func (r *TokenRepositoryImpl) RotateRefreshToken(ctx context.Context, tokenHash string, newTokenHash string, expiresAt time.Time) (int, string, sm.Device, []string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, "", sm.Device{}, nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	var (
+		id, userID           int
+		familyID             string
+		device               sm.Device
+		tokenExpiresAt       time.Time
+		rotatedAt, revokedAt *time.Time
+	)
+	query := `SELECT id, user_id, family_id, COALESCE(user_agent, ''), COALESCE(ip, ''), expires_at, rotated_at, revoked_at
+			  FROM refresh_tokens
+			  WHERE token_hash = $1
+			  FOR UPDATE`
+	err = tx.QueryRow(ctx, query, tokenHash).Scan(&id, &userID, &familyID, &device.UserAgent, &device.IP,
+		&tokenExpiresAt, &rotatedAt, &revokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, "", sm.Device{}, nil, se.ErrInvalidRefreshToken
+		}
+		return 0, "", sm.Device{}, nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if revokedAt != nil || tokenExpiresAt.Before(time.Now()) {
+		return 0, "", sm.Device{}, nil, se.ErrInvalidRefreshToken
+	}
+
+	if rotatedAt != nil {
+		revoked, err := revokeFamily(ctx, tx, familyID)
+		if err != nil {
+			return 0, "", sm.Device{}, nil, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return 0, "", sm.Device{}, nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return 0, "", sm.Device{}, revoked, se.ErrRefreshTokenReused
+	}
+
+	query = `UPDATE refresh_tokens SET rotated_at = now() WHERE id = $1`
+	_, err = tx.Exec(ctx, query, id)
+	if err != nil {
+		return 0, "", sm.Device{}, nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	query = `INSERT INTO refresh_tokens(user_id, family_id, token_hash, expires_at, user_agent, ip)
+			  VALUES($1, $2, $3, $4, $5, $6)`
+	_, err = tx.Exec(ctx, query, userID, familyID, newTokenHash, expiresAt, device.UserAgent, device.IP)
+	if err != nil {
+		return 0, "", sm.Device{}, nil, fmt.Errorf("failed to save refresh token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, "", sm.Device{}, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return userID, familyID, device, nil, nil
+}
+
+// GetSessions lists the active sessions of the user, most recently used first. A session is a
+// refresh token family that still has a usable token, so it stays listed for as long as it can
+// be refreshed, however long its access tokens have been expired. The family id is the session id.
+func (r *TokenRepositoryImpl) GetSessions(ctx context.Context, userID int) ([]rm.Session, error) {
+	query := `SELECT active.family_id, started.created_at, active.created_at, active.expires_at,
+			         COALESCE(active.user_agent, ''), COALESCE(active.ip, '')
+			  FROM refresh_tokens active
+			  JOIN LATERAL (SELECT MIN(created_at) AS created_at
+			                FROM refresh_tokens
+			                WHERE family_id = active.family_id) started ON true
+			  WHERE active.user_id = $1
+			    AND active.rotated_at IS NULL AND active.revoked_at IS NULL AND active.expires_at > now()
+			  ORDER BY active.created_at DESC, active.id DESC`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]rm.Session, 0)
+	for rows.Next() {
+		var session rm.Session
+		err := rows.Scan(&session.ID, &session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt,
+			&session.UserAgent, &session.IP)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeUserSession ends the session sessionID, a family id from GetSessions, if it is one of the
+// user's and can still be refreshed. It returns the jti of every access token it deleted, and
+// ErrNotFound if there is no such session.
+func (r *TokenRepositoryImpl) RevokeUserSession(ctx context.Context, userID int, sessionID string) ([]string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	var found bool
+	query := `SELECT EXISTS(SELECT 1 FROM refresh_tokens
+			                WHERE family_id = $1 AND user_id = $2
+			                  AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > now())`
+	err = tx.QueryRow(ctx, query, sessionID, userID).Scan(&found)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if !found {
+		return nil, se.ErrNotFound
+	}
+
+	revoked, err := revokeFamily(ctx, tx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return revoked, nil
+}
+
 func (r *TokenRepositoryImpl) CleanupExpiredTokens(ctx context.Context) error {
 	query := `DELETE FROM user_tokens WHERE expires_at < now()`
 	_, err := r.db.Exec(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to cleanup expired tokens: %w", err)
 	}
+
+	query = `DELETE FROM refresh_tokens WHERE expires_at < now()`
+	_, err = r.db.Exec(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup expired refresh tokens: %w", err)
+	}
 	return nil
 }
+
+// revokeFamily ends the session of the token family and returns the jtis of the deleted access
+// tokens, so that they stop being cached as active.
+func revokeFamily(ctx context.Context, tx pgx.Tx, familyID string) ([]string, error) {
+	query := `UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL`
+	_, err := tx.Exec(ctx, query, familyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	query = `DELETE FROM user_tokens WHERE family_id = $1 RETURNING jti`
+	return deleteAccessTokens(ctx, tx, query, familyID)
+}
+
+// revokeUserSessions ends every session of the user and returns the jtis of the deleted access
+// tokens, so that they stop being cached as active.
+func revokeUserSessions(ctx context.Context, tx pgx.Tx, userID int) ([]string, error) {
+	query := `UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`
+	_, err := tx.Exec(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	query = `DELETE FROM user_tokens WHERE user_id = $1 RETURNING jti`
+	return deleteAccessTokens(ctx, tx, query, userID)
+}
+
+// deleteAccessTokens runs a DELETE … RETURNING jti query and returns the jtis of the deleted tokens.
+func deleteAccessTokens(ctx context.Context, tx pgx.Tx, query string, args ...any) ([]string, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var jtis []string
+	for rows.Next() {
+		var jti string
+		if err := rows.Scan(&jti); err != nil {
+			return nil, fmt.Errorf("failed to scan revoked token: %w", err)
+		}
+		jtis = append(jtis, jti)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+	return jtis, nil
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/AnatolyGolang/book-shop/internal/app/logger"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// rollback is deferred right after Begin; it is a no-op once the transaction has been committed.
+func rollback(ctx context.Context, tx pgx.Tx) {
+	err := tx.Rollback(ctx)
+	if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		logger.Logger.Error("failed to rollback", zap.Error(err))
+	}
+}
@@ -0,0 +1,226 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	rm "github.com/AnatolyGolang/book-shop/internal/app/repositories/models"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	"github.com/AnatolyGolang/book-shop/internal/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type TwoFactorRepositoryImpl struct {
+	db *postgres.DBConnection
+}
+
+func NewTwoFactorRepository(db *postgres.DBConnection) *TwoFactorRepositoryImpl {
+	return &TwoFactorRepositoryImpl{db: db}
+}
+
+// SaveTOTPSecret starts or restarts the enrolment of the user. It fails with
+// ErrTwoFactorAlreadyEnabled once the enrolment has been confirmed.
+func (r *TwoFactorRepositoryImpl) SaveTOTPSecret(ctx context.Context, userID int, secret []byte) error {
+	query := `INSERT INTO user_totp (user_id, secret)
+              VALUES ($1, $2)
+              ON CONFLICT (user_id) DO UPDATE
+                  SET secret         = EXCLUDED.secret,
+                      created_at     = now(),
+                      last_used_step = NULL
+                  WHERE user_totp.confirmed_at IS NULL`
+
+	tag, err := r.db.Exec(ctx, query, userID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to save totp secret: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return se.ErrTwoFactorAlreadyEnabled
+	}
+	return nil
+}
+
+func (r *TwoFactorRepositoryImpl) GetTOTP(ctx context.Context, userID int) (rm.TOTP, error) {
+	query := `SELECT user_id, secret, created_at, confirmed_at, last_used_step
+              FROM user_totp
+              WHERE user_id = $1`
+
+	var totp rm.TOTP
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&totp.UserID, &totp.Secret, &totp.CreatedAt, &totp.ConfirmedAt, &totp.LastUsedStep)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return rm.TOTP{}, se.ErrNotFound
+		}
+		return rm.TOTP{}, fmt.Errorf("failed to get totp: %w", err)
+	}
+	return totp, nil
+}
+
+// UseTOTPStep records that the code of step was used and reports false if it, or a later one,
+// already was.
+func (r *TwoFactorRepositoryImpl) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	query := `UPDATE user_totp
+              SET last_used_step = $2
+              WHERE user_id = $1 AND confirmed_at IS NOT NULL
+                AND (last_used_step IS NULL OR last_used_step < $2)`
+
+	tag, err := r.db.Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to use totp step: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ConfirmTOTP turns two-factor authentication on, replaces the recovery codes and ends every
+// session of the user, so that all later sessions are started with a code. It returns the jtis
+// of the revoked access tokens.
+func (r *TwoFactorRepositoryImpl) ConfirmTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) ([]string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	query := `UPDATE user_totp
+              SET confirmed_at = now(), last_used_step = $2
+              WHERE user_id = $1 AND confirmed_at IS NULL`
+	tag, err := tx.Exec(ctx, query, userID, step)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm totp: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, se.ErrTwoFactorAlreadyEnabled
+	}
+
+	query = `DELETE FROM two_factor_recovery_codes WHERE user_id = $1`
+	if _, err := tx.Exec(ctx, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	query = `INSERT INTO two_factor_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+	for _, codeHash := range recoveryCodeHashes {
+		if _, err := tx.Exec(ctx, query, userID, codeHash); err != nil {
+			return nil, fmt.Errorf("failed to save recovery code: %w", err)
+		}
+	}
+
+	revoked, err := revokeUserSessions(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return revoked, nil
+}
+
+// DisableTOTP turns two-factor authentication off and drops the recovery codes.
+func (r *TwoFactorRepositoryImpl) DisableTOTP(ctx context.Context, userID int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	query := `DELETE FROM user_totp WHERE user_id = $1`
+	if _, err := tx.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to delete totp: %w", err)
+	}
+
+	query = `DELETE FROM two_factor_recovery_codes WHERE user_id = $1`
+	if _, err := tx.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// UseRecoveryCode uses up one of the user's recovery codes and reports false if it does not
+// exist or was already used.
+func (r *TwoFactorRepositoryImpl) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	query := `UPDATE two_factor_recovery_codes
+              SET used_at = now()
+              WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	tag, err := r.db.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *TwoFactorRepositoryImpl) GetTwoFactorState(ctx context.Context, userID int) (rm.TwoFactorState, error) {
+	query := `SELECT EXISTS(SELECT 1
+                            FROM user_roles ur
+                            JOIN roles ro ON ro.id = ur.role_id
+                            WHERE ur.user_id = $1 AND ro.name = 'admin'),
+                     EXISTS(SELECT 1 FROM user_totp WHERE user_id = $1 AND confirmed_at IS NOT NULL)`
+
+	var state rm.TwoFactorState
+	err := r.db.QueryRow(ctx, query, userID).Scan(&state.IsAdmin, &state.Enabled)
+	if err != nil {
+		return rm.TwoFactorState{}, fmt.Errorf("failed to get two-factor state: %w", err)
+	}
+	return state, nil
+}
+
+func (r *TwoFactorRepositoryImpl) CreateChallenge(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	query := `INSERT INTO two_factor_challenges (user_id, token_hash, expires_at)
+              VALUES ($1, $2, $3)`
+
+	_, err := r.db.Exec(ctx, query, userID, tokenHash, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to save two-factor challenge: %w", err)
+	}
+	return nil
+}
+
+// AttemptChallenge counts an attempt to answer the challenge and returns its id and user. A
+// challenge that is used, expired or out of attempts fails with ErrInvalidTwoFactorChallenge.
+func (r *TwoFactorRepositoryImpl) AttemptChallenge(ctx context.Context, tokenHash string, maxAttempts int) (int, int, error) {
+	query := `UPDATE two_factor_challenges
+              SET attempts = attempts + 1
+              WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now() AND attempts < $2
+              RETURNING id, user_id`
+
+	var id, userID int
+	err := r.db.QueryRow(ctx, query, tokenHash, maxAttempts).Scan(&id, &userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, 0, se.ErrInvalidTwoFactorChallenge
+		}
+		return 0, 0, fmt.Errorf("failed to attempt two-factor challenge: %w", err)
+	}
+	return id, userID, nil
+}
+
+// CompleteChallenge uses up the challenge. It fails with ErrInvalidTwoFactorChallenge if a
+// concurrent attempt got there first.
+func (r *TwoFactorRepositoryImpl) CompleteChallenge(ctx context.Context, challengeID int) error {
+	query := `UPDATE two_factor_challenges SET used_at = now() WHERE id = $1 AND used_at IS NULL`
+
+	tag, err := r.db.Exec(ctx, query, challengeID)
+	if err != nil {
+		return fmt.Errorf("failed to complete two-factor challenge: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return se.ErrInvalidTwoFactorChallenge
+	}
+	return nil
+}
+
+func (r *TwoFactorRepositoryImpl) CleanupExpiredChallenges(ctx context.Context) error {
+	query := `DELETE FROM two_factor_challenges WHERE expires_at < now()`
+	_, err := r.db.Exec(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup expired two-factor challenges: %w", err)
+	}
+	return nil
+}
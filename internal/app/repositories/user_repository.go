@@ -6,12 +6,25 @@ import (
 	"fmt"
 
 	rm "github.com/AnatolyGolang/book-shop/internal/app/repositories/models"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
 	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
 	"github.com/AnatolyGolang/book-shop/internal/pkg/postgres"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// uniqueViolation is the SQLSTATE of an insert or update that breaks a unique index.
+const uniqueViolation = "23505"
+
+// userColumns derives the admin flag from membership in the admin role.
+const userColumns = `id, email, password, display_name,
+              EXISTS(SELECT 1
+                FROM user_roles ur
+                JOIN roles ro ON ro.id = ur.role_id
+                WHERE ur.user_id = users.id AND ro.name = 'admin') AS is_admin,
+              disabled_at, email_verified_at, created_at, updated_at`
+
 type UserRepositoryImpl struct {
 	db *postgres.DBConnection
 }
@@ -25,18 +38,14 @@ func (r *UserRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (
 		return rm.User{}, fmt.Errorf("email can not be empty")
 	}
 
-	var user rm.User
-	query := `SELECT id, email, password, is_admin, created_at, updated_at 
+	query := `SELECT ` + userColumns + `
               	FROM users
               WHERE email = $1`
 
-	row := r.db.QueryRow(ctx, query, email)
-	err := row.Scan(
-		&user.Id, &user.Email, &user.Password, &user.IsAdmin, &user.CreatedAt, &user.UpdatedAt)
-
+	user, err := scanUser(r.db.QueryRow(ctx, query, email))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return rm.User{}, fmt.Errorf("user not found")
+			return rm.User{}, se.ErrNotFound
 		}
 		return rm.User{}, fmt.Errorf("failed to get a user: %w", err)
 	}
@@ -47,14 +56,13 @@ func (r *UserRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (
 func (r *UserRepositoryImpl) CreateUser(ctx context.Context, user sm.DomainUser) (rm.User, error) {
 	query := `INSERT INTO users (email, password) 
               VALUES ($1, $2) 
-              RETURNING id, email, password, is_admin, created_at, updated_at`
+              RETURNING ` + userColumns
 
-	var newUser rm.User
-	err := r.db.QueryRow(ctx, query, user.Email, user.Password).Scan(
-		&newUser.Id, &newUser.Email, &newUser.Password, &newUser.IsAdmin,
-		&newUser.CreatedAt, &newUser.UpdatedAt,
-	)
+	newUser, err := scanUser(r.db.QueryRow(ctx, query, user.Email, user.Password))
 	if err != nil {
+		if isUniqueViolation(err) {
+			return rm.User{}, se.ErrEmailTaken
+		}
 		return rm.User{}, fmt.Errorf("failed to create user: %w", err)
 	}
 
@@ -66,21 +74,281 @@ func (r *UserRepositoryImpl) GetUserById(ctx context.Context, id int) (rm.User,
 		return rm.User{}, fmt.Errorf("id can not be 0")
 	}
 
-	var user rm.User
-	query := `SELECT id, email, password, is_admin, created_at, updated_at 
+	query := `SELECT ` + userColumns + `
               	FROM users
               WHERE id = $1`
 
-	row := r.db.QueryRow(ctx, query, id)
-	err := row.Scan(
-		&user.Id, &user.Email, &user.Password, &user.IsAdmin, &user.CreatedAt, &user.UpdatedAt)
-
+	user, err := scanUser(r.db.QueryRow(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return rm.User{}, fmt.Errorf("user not found")
+			return rm.User{}, se.ErrNotFound
 		}
 		return rm.User{}, fmt.Errorf("failed to get a user: %w", err)
 	}
 
 	return user, nil
 }
+
+// GetUsers lists users whose email contains search, or all users when search is empty.
+func (r *UserRepositoryImpl) GetUsers(ctx context.Context, search string, limit int, offset int) ([]rm.User, int, error) {
+	pattern := "%" + escapeLike(search) + "%"
+
+	query := `SELECT ` + userColumns + `
+        	  FROM users
+        	  WHERE email ILIKE $1
+              ORDER BY id
+              LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(ctx, query, pattern, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []rm.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	countQuery := `SELECT COUNT(*) FROM users WHERE email ILIKE $1`
+	var total int
+	err = r.db.QueryRow(ctx, countQuery, pattern).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return users, total, nil
+}
+
+func (r *UserRepositoryImpl) SetAdmin(ctx context.Context, actorID int, userID int, isAdmin bool) (rm.User, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return rm.User{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	if err := lockUser(ctx, tx, userID); err != nil {
+		return rm.User{}, err
+	}
+
+	query := `DELETE FROM user_roles
+              WHERE user_id = $1 AND role_id = (SELECT id FROM roles WHERE name = 'admin')`
+	action := rm.AuditActionRevokeAdmin
+	if isAdmin {
+		query = `INSERT INTO user_roles (user_id, role_id)
+                 SELECT $1, id FROM roles WHERE name = 'admin'
+                 ON CONFLICT DO NOTHING`
+		action = rm.AuditActionGrantAdmin
+	}
+
+	tag, err := tx.Exec(ctx, query, userID)
+	if err != nil {
+		return rm.User{}, fmt.Errorf("failed to update roles: %w", err)
+	}
+
+	err = recordAudit(ctx, tx, actorID, action, userID, map[string]any{"changed": tag.RowsAffected() > 0})
+	if err != nil {
+		return rm.User{}, err
+	}
+
+	user, err := getUserTx(ctx, tx, userID)
+	if err != nil {
+		return rm.User{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return rm.User{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return user, nil
+}
+
+// SetDisplayName sets the display name of the user, or clears it when displayName is nil.
+func (r *UserRepositoryImpl) SetDisplayName(ctx context.Context, userID int, displayName *string) (rm.User, error) {
+	query := `UPDATE users
+              SET display_name = $2,
+                  updated_at   = now()
+              WHERE id = $1
+              RETURNING ` + userColumns
+
+	user, err := scanUser(r.db.QueryRow(ctx, query, userID, displayName))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return rm.User{}, se.ErrNotFound
+		}
+		return rm.User{}, fmt.Errorf("failed to update user: %w", err)
+	}
+	return user, nil
+}
+
+// SetDisabled disables or re-enables an account. Disabling also ends all of the user's sessions;
+// the jtis of the revoked access tokens are returned.
+func (r *UserRepositoryImpl) SetDisabled(ctx context.Context, actorID int, userID int, disabled bool) (rm.User, []string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return rm.User{}, nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	query := `UPDATE users
+              SET disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, now()) END,
+                  updated_at  = now()
+              WHERE id = $1
+              RETURNING ` + userColumns
+
+	user, err := scanUser(tx.QueryRow(ctx, query, userID, disabled))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return rm.User{}, nil, se.ErrNotFound
+		}
+		return rm.User{}, nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	action := rm.AuditActionEnableUser
+	details := map[string]any{}
+	var revoked []string
+	if disabled {
+		action = rm.AuditActionDisableUser
+		revoked, err = revokeUserSessions(ctx, tx, userID)
+		if err != nil {
+			return rm.User{}, nil, err
+		}
+		details["sessions_revoked"] = len(revoked)
+	}
+
+	if err := recordAudit(ctx, tx, actorID, action, userID, details); err != nil {
+		return rm.User{}, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return rm.User{}, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return user, revoked, nil
+}
+
+// ForceLogout ends every session of the user and returns the jtis of the revoked access tokens.
+func (r *UserRepositoryImpl) ForceLogout(ctx context.Context, actorID int, userID int) ([]string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	if err := lockUser(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	revoked, err := revokeUserSessions(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = recordAudit(ctx, tx, actorID, rm.AuditActionForceLogout, userID, map[string]any{"sessions_revoked": len(revoked)})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return revoked, nil
+}
+
+// RehashPassword replaces the hash of an unchanged password with one of the current scheme. It
+// does nothing if the password was changed in the meantime, and keeps the sessions.
+func (r *UserRepositoryImpl) RehashPassword(ctx context.Context, userID int, oldHash string, newHash string) error {
+	query := `UPDATE users SET password = $3 WHERE id = $1 AND password = $2`
+
+	_, err := r.db.Exec(ctx, query, userID, oldHash, newHash)
+	if err != nil {
+		return fmt.Errorf("failed to rehash password: %w", err)
+	}
+	return nil
+}
+
+// ChangePassword sets a new password and ends all sessions of the user, including the current one.
+// It returns the jtis of the revoked access tokens.
+func (r *UserRepositoryImpl) ChangePassword(ctx context.Context, userID int, passwordHash string) ([]string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer rollback(ctx, tx)
+
+	revoked, err := setPassword(ctx, tx, userID, passwordHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return revoked, nil
+}
+
+// setPassword replaces the password hash and revokes every session, so that whoever knew the
+// old password is logged out. It returns the jtis of the revoked access tokens.
+func setPassword(ctx context.Context, tx pgx.Tx, userID int, passwordHash string) ([]string, error) {
+	query := `UPDATE users SET password = $2, updated_at = now() WHERE id = $1`
+	tag, err := tx.Exec(ctx, query, userID, passwordHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update password: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, se.ErrNotFound
+	}
+
+	query = `DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`
+	_, err = tx.Exec(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete reset tokens: %w", err)
+	}
+
+	return revokeUserSessions(ctx, tx, userID)
+}
+
+func lockUser(ctx context.Context, tx pgx.Tx, userID int) error {
+	var id int
+	query := `SELECT id FROM users WHERE id = $1 FOR UPDATE`
+	err := tx.QueryRow(ctx, query, userID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return se.ErrNotFound
+		}
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+	return nil
+}
+
+func getUserTx(ctx context.Context, tx pgx.Tx, userID int) (rm.User, error) {
+	query := `SELECT ` + userColumns + `
+              	FROM users
+              WHERE id = $1`
+
+	user, err := scanUser(tx.QueryRow(ctx, query, userID))
+	if err != nil {
+		return rm.User{}, fmt.Errorf("failed to get a user: %w", err)
+	}
+	return user, nil
+}
+
+func scanUser(row pgx.Row) (rm.User, error) {
+	var user rm.User
+	err := row.Scan(
+		&user.Id, &user.Email, &user.Password, &user.DisplayName, &user.IsAdmin, &user.DisabledAt, &user.EmailVerifiedAt,
+		&user.CreatedAt, &user.UpdatedAt)
+	return user, err
+}
+
+// isUniqueViolation reports whether err was caused by a unique constraint or index.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	r "github.com/AnatolyGolang/book-shop/internal/app/repositories"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
+	"github.com/AnatolyGolang/book-shop/internal/pkg/mailer"
+)
+
+type AccountServiceImpl struct {
+	verificationRepository  r.EmailVerificationRepository
+	passwordResetRepository r.PasswordResetRepository
+	userRepository          r.UserRepository
+	loginThrottleRepository r.LoginThrottleRepository
+	passwordHasher          PasswordHasher
+	passwordPolicy          PasswordPolicy
+	lockoutPolicy           models.LockoutPolicy
+	mailer                  mailer.Mailer
+	tokenRevoker            TokenRevoker
+	publicBaseURL           string
+	verificationTTL         time.Duration
+	passwordResetTTL        time.Duration
+}
+
+func NewAccountService(verificationRepo r.EmailVerificationRepository, resetRepo r.PasswordResetRepository,
+	userRepo r.UserRepository, throttleRepo r.LoginThrottleRepository, hasher PasswordHasher, policy PasswordPolicy,
+	lockoutPolicy models.LockoutPolicy, m mailer.Mailer, tokenRevoker TokenRevoker, publicBaseURL string,
+	verificationTTL time.Duration, passwordResetTTL time.Duration) *AccountServiceImpl {
+	return &AccountServiceImpl{
+		verificationRepository:  verificationRepo,
+		passwordResetRepository: resetRepo,
+		userRepository:          userRepo,
+		loginThrottleRepository: throttleRepo,
+		passwordHasher:          hasher,
+		passwordPolicy:          policy,
+		lockoutPolicy:           lockoutPolicy,
+		mailer:                  m,
+		tokenRevoker:            tokenRevoker,
+		publicBaseURL:           publicBaseURL,
+		verificationTTL:         verificationTTL,
+		passwordResetTTL:        passwordResetTTL,
+	}
+}
+
+// SendVerificationEmail issues a new verification token for the user and mails it to them.
+func (s *AccountServiceImpl) SendVerificationEmail(ctx context.Context, userID int) error {
+	user, err := s.userRepository.GetUserById(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return se.ErrEmailAlreadyVerified
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	err = s.verificationRepository.CreateVerificationToken(ctx, user.Id, hashToken(token), time.Now().Add(s.verificationTTL))
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Welcome to the book shop!\r\n\r\n"+
+			"To confirm your email address, send this token to POST %s/verify-email:\r\n\r\n%s\r\n\r\n"+
+			"The token expires in %s. If you did not sign up, ignore this email.\r\n",
+			s.publicBaseURL, token, s.verificationTTL),
+	})
+}
+
+func (s *AccountServiceImpl) VerifyEmail(ctx context.Context, token string) (models.DomainUser, error) {
+	userID, err := s.verificationRepository.VerifyEmail(ctx, hashToken(token))
+	if err != nil {
+		return models.DomainUser{}, err
+	}
+
+	user, err := s.userRepository.GetUserById(ctx, userID)
+	if err != nil {
+		return models.DomainUser{}, err
+	}
+	return models.ToDomainUser(user), nil
+}
+
+// RequestPasswordReset mails a reset token to the owner of email, unless the account is unknown
+// or disabled. The work is done in the background and failures are only logged: the caller
+// returns at once either way, so neither the result nor the response time tells which emails exist.
+func (s *AccountServiceImpl) RequestPasswordReset(ctx context.Context, email string) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := s.sendPasswordReset(ctx, email); err != nil {
+			log.Printf("failed to send password reset email: %v", err)
+		}
+	}()
+}
+
+func (s *AccountServiceImpl) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := s.userRepository.GetUserByEmail(ctx, models.CanonicalEmail(email))
+	if err != nil {
+		if errors.Is(err, se.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.DisabledAt != nil {
+		return nil
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	err = s.passwordResetRepository.CreateResetToken(ctx, user.Id, hashToken(token), time.Now().Add(s.passwordResetTTL))
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password of your book shop account.\r\n\r\n"+
+			"To choose a new password, send this token with it to POST %s/password/reset:\r\n\r\n%s\r\n\r\n"+
+			"The token expires in %s. If it was not you, ignore this email; your password stays the same.\r\n",
+			s.publicBaseURL, token, s.passwordResetTTL),
+	})
+}
+
+// ResetPassword sets a new password with a token from RequestPasswordReset and logs the user
+// out everywhere.
+func (s *AccountServiceImpl) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	validationErr := &se.ValidationError{}
+	checkPassword(s.passwordPolicy, "password", newPassword, validationErr)
+	if err := validationErr.Err(); err != nil {
+		return err
+	}
+
+	passwordHash, err := s.passwordHasher.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	_, revoked, err := s.passwordResetRepository.ResetPassword(ctx, hashToken(token), passwordHash)
+	if err != nil {
+		return err
+	}
+	s.tokenRevoker.MarkRevoked(revoked)
+	return nil
+}
+
+// ChangePassword replaces the password of a signed-in user who knows the current one, and logs
+// them out everywhere. A wrong current password counts towards the sign-in lockout of the
+// account and of ip, so that a stolen session can not be used to guess the password.
+func (s *AccountServiceImpl) ChangePassword(ctx context.Context, userID int, currentPassword string, newPassword string, ip string) error {
+	validationErr := &se.ValidationError{}
+	checkPassword(s.passwordPolicy, "new_password", newPassword, validationErr)
+	if err := validationErr.Err(); err != nil {
+		return err
+	}
+
+	user, err := s.userRepository.GetUserById(ctx, userID)
+	if err != nil {
+		return err
+	}
+	account := loginAccountKey(user.Email)
+
+	lockedUntil, err := s.loginThrottleRepository.GetLockedUntil(ctx, account, ip)
+	if err != nil {
+		return err
+	}
+	if lockedUntil != nil {
+		return se.NewLockedError(*lockedUntil)
+	}
+
+	ok, _ := verifyPassword(s.passwordHasher, user.Id, currentPassword, user.Password)
+	if !ok {
+		if err := s.loginThrottleRepository.RecordFailure(ctx, account, ip, s.lockoutPolicy); err != nil {
+			return err
+		}
+		return se.ErrInvalidCurrentPassword
+	}
+
+	if err := s.loginThrottleRepository.ResetAccount(ctx, account); err != nil {
+		return err
+	}
+
+	passwordHash, err := s.passwordHasher.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	revoked, err := s.userRepository.ChangePassword(ctx, userID, passwordHash)
+	if err != nil {
+		return err
+	}
+	s.tokenRevoker.MarkRevoked(revoked)
+	return nil
+}
@@ -4,16 +4,19 @@ import (
 	"context"
 
 	r "github.com/AnatolyGolang/book-shop/internal/app/repositories"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
 	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
 )
 
 type BookServiceImpl struct {
-	repository r.BookRepository
+	repository      r.BookRepository
+	stockRepository r.StockMovementRepository
 }
 
-func NewBookService(repo r.BookRepository) *BookServiceImpl {
+func NewBookService(repo r.BookRepository, stockRepo r.StockMovementRepository) *BookServiceImpl {
 	return &BookServiceImpl{
-		repository: repo,
+		repository:      repo,
+		stockRepository: stockRepo,
 	}
 }
 
@@ -26,7 +29,7 @@ func (s *BookServiceImpl) GetBook(ctx context.Context, id int) (models.DomainBoo
 }
 
 func (s *BookServiceImpl) CreateBook(ctx context.Context, domainBook models.DomainBook) (models.DomainBook, error) {
-	book, err := s.repository.CreateBook(ctx, domainBook)
+	book, err := s.repository.CreateBook(ctx, domainBook, actorID(ctx))
 	if err != nil {
 		return models.DomainBook{}, err
 	}
@@ -42,6 +45,15 @@ func (s *BookServiceImpl) UpdateBook(ctx context.Context, id int, domainBook mod
 	return models.ToDomainBook(book), nil
 }
 
+func (s *BookServiceImpl) RestockBook(ctx context.Context, id int, amount int, reason string) (models.DomainBook, error) {
+	book, err := s.repository.RestockBook(ctx, id, amount, reason, actorID(ctx))
+	if err != nil {
+		return models.DomainBook{}, err
+	}
+
+	return models.ToDomainBook(book), nil
+}
+
 func (s *BookServiceImpl) DeleteBook(ctx context.Context, id int) error {
 	err := s.repository.DeleteBook(ctx, id)
 	if err != nil {
@@ -51,8 +63,8 @@ func (s *BookServiceImpl) DeleteBook(ctx context.Context, id int) error {
 	return nil
 }
 
-func (s *BookServiceImpl) GetBooksByCategories(ctx context.Context, categoryIDs []int, limit int, offset int) ([]models.DomainBook, int, error) {
-	books, total, err := s.repository.GetBooksByCategories(ctx, categoryIDs, limit, offset)
+func (s *BookServiceImpl) GetBooks(ctx context.Context, filter models.BookFilter) ([]models.DomainBook, int, error) {
+	books, total, err := s.repository.GetBooks(ctx, filter)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -64,3 +76,88 @@ func (s *BookServiceImpl) GetBooksByCategories(ctx context.Context, categoryIDs
 
 	return domainBooks, total, nil
 }
+
+func (s *BookServiceImpl) GetBooksByCursor(ctx context.Context, filter models.BookFilter, cursor models.BookCursor) ([]models.DomainBook, bool, error) {
+	books, hasMore, err := s.repository.GetBooksByCursor(ctx, filter, cursor)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var domainBooks []models.DomainBook
+	for _, book := range books {
+		domainBooks = append(domainBooks, models.ToDomainBook(book))
+	}
+
+	return domainBooks, hasMore, nil
+}
+
+func (s *BookServiceImpl) SearchBooks(ctx context.Context, text string, includeSoldOut bool, limit int, offset int) ([]models.DomainBookSearchResult, int, error) {
+	results, total, err := s.repository.SearchBooks(ctx, text, includeSoldOut, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var domainResults []models.DomainBookSearchResult
+	for _, result := range results {
+		domainResults = append(domainResults, models.ToDomainBookSearchResult(result))
+	}
+
+	return domainResults, total, nil
+}
+
+func (s *BookServiceImpl) GetBooksByIDs(ctx context.Context, ids []int) ([]models.DomainBook, error) {
+	books, err := s.repository.GetBooksByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var domainBooks []models.DomainBook
+	for _, book := range books {
+		domainBooks = append(domainBooks, models.ToDomainBook(book))
+	}
+
+	return domainBooks, nil
+}
+
+// CheckAvailability reports whether quantity copies of the book can be bought right now.
+// It is a fast pre-check without locks; the reservation itself re-checks stock under a row lock.
+func (s *BookServiceImpl) CheckAvailability(ctx context.Context, id int, quantity int) error {
+	book, err := s.repository.GetBook(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if book.Amount < quantity {
+		return se.NewOutOfStockError(id)
+	}
+
+	return nil
+}
+
+func (s *BookServiceImpl) GetStockHistory(ctx context.Context, bookID int, limit int, offset int) ([]models.DomainStockMovement, int, error) {
+	_, err := s.repository.GetBook(ctx, bookID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	movements, total, err := s.stockRepository.GetStockMovements(ctx, bookID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var domainMovements []models.DomainStockMovement
+	for _, movement := range movements {
+		domainMovements = append(domainMovements, models.ToDomainStockMovement(movement))
+	}
+
+	return domainMovements, total, nil
+}
+
+// actorID returns the id of the user behind the request, if any, to be recorded in the stock ledger.
+func actorID(ctx context.Context) *int {
+	user, err := models.GetUserFromContext(ctx)
+	if err != nil {
+		return nil
+	}
+	return &user.Id
+}
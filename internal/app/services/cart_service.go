@@ -7,30 +7,115 @@ import (
 	"time"
 
 	r "github.com/AnatolyGolang/book-shop/internal/app/repositories"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
 )
 
 type CartServiceImpl struct {
-	repository r.CartRepository
+	repository     r.CartRepository
+	bookService    BookService
+	userService    UserService
+	reservationTTL time.Duration
+	// requireVerifiedEmail refuses checkout to users who have not verified their email yet.
+	requireVerifiedEmail bool
 }
 
-func NewCartService(repo r.CartRepository) *CartServiceImpl {
-	return &CartServiceImpl{repository: repo}
+func NewCartService(repo r.CartRepository, bookService BookService, userService UserService,
+	reservationTTL time.Duration, requireVerifiedEmail bool) *CartServiceImpl {
+	return &CartServiceImpl{
+		repository:           repo,
+		bookService:          bookService,
+		userService:          userService,
+		reservationTTL:       reservationTTL,
+		requireVerifiedEmail: requireVerifiedEmail,
+	}
 }
 
-func (s *CartServiceImpl) UpdateCart(ctx context.Context, userID int, bookIds []int) error {
-	err := s.repository.UpdateCart(ctx, userID, bookIds)
+func (s *CartServiceImpl) UpdateCart(ctx context.Context, userID int, items []models.DomainCartItem) error {
+	for _, item := range items {
+		if err := s.bookService.CheckAvailability(ctx, item.BookID, item.Quantity); err != nil {
+			return fmt.Errorf("error adding books to cart: %w", err)
+		}
+	}
+
+	err := s.repository.UpdateCart(ctx, userID, items)
 	if err != nil {
 		return fmt.Errorf("error adding books to cart: %w", err)
 	}
 	return nil
 }
 
+func (s *CartServiceImpl) Checkout(ctx context.Context, userID int) (models.DomainOrder, error) {
+	if s.requireVerifiedEmail {
+		user, err := s.userService.GetUserById(ctx, userID)
+		if err != nil {
+			return models.DomainOrder{}, fmt.Errorf("error checking out cart: %w", err)
+		}
+		if !user.IsEmailVerified() {
+			return models.DomainOrder{}, se.ErrEmailNotVerified
+		}
+	}
+
+	order, err := s.repository.Checkout(ctx, userID)
+	if err != nil {
+		return models.DomainOrder{}, fmt.Errorf("error checking out cart: %w", err)
+	}
+	return models.ToDomainOrder(order), nil
+}
+
+func (s *CartServiceImpl) GetCart(ctx context.Context, userID int) (models.DomainCart, error) {
+	items, err := s.repository.GetCart(ctx, userID)
+	if err != nil {
+		return models.DomainCart{}, fmt.Errorf("error getting cart: %w", err)
+	}
+
+	bookIds := make([]int, len(items))
+	for i, item := range items {
+		bookIds[i] = item.BookID
+	}
+
+	books, err := s.bookService.GetBooksByIDs(ctx, bookIds)
+	if err != nil {
+		return models.DomainCart{}, fmt.Errorf("error getting cart books: %w", err)
+	}
+
+	booksByID := make(map[int]models.DomainBook, len(books))
+	for _, book := range books {
+		booksByID[book.ID] = book
+	}
+
+	var cart models.DomainCart
+	for _, item := range items {
+		book, ok := booksByID[item.BookID]
+		if !ok {
+			continue
+		}
+		cart.Items = append(cart.Items, models.DomainCartLine{
+			Book:       book,
+			Quantity:   item.Quantity,
+			ReservedAt: item.ReservedAt,
+			ExpiresAt:  item.ReservedAt.Add(s.reservationTTL),
+		})
+		cart.TotalPrice += book.Price * item.Quantity
+	}
+
+	return cart, nil
+}
+
+func (s *CartServiceImpl) RemoveFromCart(ctx context.Context, userID int, bookID int) error {
+	err := s.repository.RemoveFromCart(ctx, userID, bookID)
+	if err != nil {
+		return fmt.Errorf("error removing book from cart: %w", err)
+	}
+	return nil
+}
+
 func (s *CartServiceImpl) CartCleanupScheduler() {
 	ticker := time.NewTicker(1 * time.Minute)
 	go func() {
 		for {
 			<-ticker.C
-			err := s.repository.CleanupExpiredCartItems(context.Background())
+			err := s.repository.CleanupExpiredCartItems(context.Background(), s.reservationTTL)
 			if err != nil {
 				log.Printf("cart cleanup error: %v", err)
 			}
@@ -1,6 +1,11 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 var (
 	ErrRequired        = errors.New("required value")
@@ -10,4 +15,102 @@ var (
 	ErrInvalidUserID   = errors.New("invalid user ID")
 	ErrInvalidBookIDs  = errors.New("invalid book IDs")
 	ErrNoUserInContext = errors.New("no user in context")
+	ErrEmptyCart       = errors.New("cart is empty")
+	ErrOutOfStock      = errors.New("out of stock")
+	ErrTokenRevoked    = errors.New("token revoked")
+
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+
+	ErrAccountDisabled    = errors.New("account disabled")
+	ErrSelfAction         = errors.New("action not allowed on own account")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrAccountLocked      = errors.New("too many failed sign-in attempts")
+
+	ErrEmailTaken               = errors.New("email already registered")
+	ErrInvalidVerificationToken = errors.New("invalid verification token")
+	ErrEmailAlreadyVerified     = errors.New("email already verified")
+	ErrEmailNotVerified         = errors.New("email not verified")
+	ErrInvalidResetToken        = errors.New("invalid password reset token")
+	ErrInvalidCurrentPassword   = errors.New("invalid current password")
+
+	ErrValidation = errors.New("validation failed")
+
+	ErrTwoFactorRequired         = errors.New("two-factor authentication required")
+	ErrTwoFactorAlreadyEnabled   = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnabled       = errors.New("two-factor authentication not enabled")
+	ErrInvalidTwoFactorCode      = errors.New("invalid two-factor code")
+	ErrInvalidTwoFactorChallenge = errors.New("invalid two-factor challenge")
 )
+
+// OutOfStockError is returned when a book does not have enough copies left to be bought.
+// It matches ErrOutOfStock with errors.Is.
+type OutOfStockError struct {
+	BookID int
+}
+
+func NewOutOfStockError(bookID int) *OutOfStockError {
+	return &OutOfStockError{BookID: bookID}
+}
+
+func (e *OutOfStockError) Error() string {
+	return fmt.Sprintf("book %d is out of stock", e.BookID)
+}
+
+func (e *OutOfStockError) Is(target error) bool {
+	return target == ErrOutOfStock
+}
+
+// LockedError is returned when sign-in is refused because of too many failed attempts.
+// It matches ErrAccountLocked with errors.Is.
+type LockedError struct {
+	Until time.Time
+}
+
+func NewLockedError(until time.Time) *LockedError {
+	return &LockedError{Until: until}
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("sign-in locked until %s", e.Until.Format(time.RFC3339))
+}
+
+func (e *LockedError) Is(target error) bool {
+	return target == ErrAccountLocked
+}
+
+type FieldError struct {
+	Field   string
+	Slug    string
+	Message string
+}
+
+// ValidationError lists every invalid field of a request, so that they can all be reported at
+// once. It matches ErrValidation with errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Add(field string, slug string, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Slug: slug, Message: message})
+}
+
+// Err returns e if any field was added and nil otherwise.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", field.Field, field.Message))
+	}
+	return fmt.Sprintf("validation failed: %s", strings.Join(messages, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
@@ -4,14 +4,21 @@ import (
 	"context"
 
 	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
+	"github.com/AnatolyGolang/book-shop/internal/pkg/password"
 )
 
 type BookService interface {
 	GetBook(ctx context.Context, id int) (models.DomainBook, error)
 	CreateBook(ctx context.Context, book models.DomainBook) (models.DomainBook, error)
 	UpdateBook(ctx context.Context, id int, book models.DomainBook) (models.DomainBook, error)
+	RestockBook(ctx context.Context, id int, amount int, reason string) (models.DomainBook, error)
 	DeleteBook(ctx context.Context, id int) error
-	GetBooksByCategories(ctx context.Context, categoryIDs []int, limit int, offset int) ([]models.DomainBook, int, error)
+	GetBooks(ctx context.Context, filter models.BookFilter) ([]models.DomainBook, int, error)
+	GetBooksByCursor(ctx context.Context, filter models.BookFilter, cursor models.BookCursor) ([]models.DomainBook, bool, error)
+	SearchBooks(ctx context.Context, text string, includeSoldOut bool, limit int, offset int) ([]models.DomainBookSearchResult, int, error)
+	GetBooksByIDs(ctx context.Context, ids []int) ([]models.DomainBook, error)
+	CheckAvailability(ctx context.Context, id int, quantity int) error
+	GetStockHistory(ctx context.Context, bookID int, limit int, offset int) ([]models.DomainStockMovement, int, error)
 }
 
 type CategoryService interface {
@@ -22,20 +29,91 @@ type CategoryService interface {
 	GetCategories(ctx context.Context) ([]models.DomainCategory, error)
 }
 
+// PasswordHasher hashes new passwords with the current scheme and verifies hashes of any
+// scheme still supported. rehash is set when a verified hash should be replaced.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password string, hash string) (ok bool, rehash bool, err error)
+}
+
+// PasswordPolicy decides which new passwords are acceptable.
+type PasswordPolicy interface {
+	Check(password string) []password.Violation
+}
+
 type UserService interface {
 	CreateUser(ctx context.Context, user models.DomainUser) (models.DomainUser, error)
 	GetUserByName(ctx context.Context, name string) (models.DomainUser, error)
 	GetUserById(ctx context.Context, id int) (models.DomainUser, error)
+	GetUsers(ctx context.Context, search string, limit int, offset int) ([]models.DomainUser, int, error)
+	SetAdmin(ctx context.Context, userID int, isAdmin bool) (models.DomainUser, error)
+	SetDisabled(ctx context.Context, userID int, disabled bool) (models.DomainUser, error)
+	UpdateProfile(ctx context.Context, userID int, update models.ProfileUpdate) (models.DomainUser, error)
+	ForceLogout(ctx context.Context, userID int) (int, error)
+	GetAuditLog(ctx context.Context, userID int, limit int, offset int) ([]models.DomainAuditEntry, int, error)
+	Authenticate(ctx context.Context, email string, password string, ip string) (models.DomainUser, error)
+	GetLockout(ctx context.Context, userID int) (models.DomainLockout, error)
+	UnlockUser(ctx context.Context, userID int) error
+	LoginThrottleCleanupScheduler()
+}
+
+type AccountService interface {
+	SendVerificationEmail(ctx context.Context, userID int) error
+	VerifyEmail(ctx context.Context, token string) (models.DomainUser, error)
+	RequestPasswordReset(ctx context.Context, email string)
+	ResetPassword(ctx context.Context, token string, newPassword string) error
+	ChangePassword(ctx context.Context, userID int, currentPassword string, newPassword string, ip string) error
+}
+
+// SecretBox encrypts secrets that are stored in the database.
+type SecretBox interface {
+	Seal(plaintext []byte, additionalData []byte) ([]byte, error)
+	Open(sealed []byte, additionalData []byte) ([]byte, error)
+}
+
+type TwoFactorService interface {
+	BeginEnrollment(ctx context.Context, userID int) (models.TOTPEnrollment, error)
+	ConfirmEnrollment(ctx context.Context, userID int, code string) ([]string, error)
+	Disable(ctx context.Context, userID int, code string) error
+	IsEnabled(ctx context.Context, userID int) (bool, error)
+	CheckRequirement(ctx context.Context, userID int) error
+	StartChallenge(ctx context.Context, userID int) (models.TwoFactorChallenge, error)
+	CompleteChallenge(ctx context.Context, challengeToken string, code string, ip string) (models.DomainUser, error)
+	ChallengeCleanupScheduler()
+}
+
+type RoleService interface {
+	GetPermissions(ctx context.Context, userID int) (models.Permissions, error)
+	PermissionCacheCleanupScheduler()
+}
+
+// TokenRevoker is told about access tokens revoked outside of the JWT service.
+type TokenRevoker interface {
+	MarkRevoked(jtis []string)
 }
 
 type JWTService interface {
-	GenerateJWT(ctx context.Context, user models.DomainUser) (string, error)
+	IssueTokens(ctx context.Context, user models.DomainUser, device models.Device) (models.TokenPair, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (models.TokenPair, error)
 	GetUser(ctx context.Context, token string) (models.DomainUser, error)
 	RevokeToken(ctx context.Context, token string) error
+	GetSessions(ctx context.Context, userID int) ([]models.DomainSession, error)
+	RevokeSession(ctx context.Context, userID int, sessionID string) error
+	MarkRevoked(jtis []string)
+	PublicKeys() []models.PublicKey
 	StartTokenCleanupScheduler()
 }
 
 type CartService interface {
-	UpdateCart(ctx context.Context, userID int, bookIds []int) error
+	UpdateCart(ctx context.Context, userID int, items []models.DomainCartItem) error
+	Checkout(ctx context.Context, userID int) (models.DomainOrder, error)
+	GetCart(ctx context.Context, userID int) (models.DomainCart, error)
+	RemoveFromCart(ctx context.Context, userID int, bookID int) error
 	CartCleanupScheduler()
 }
+
+type OrderService interface {
+	GetOrders(ctx context.Context, userID int, limit int, offset int) ([]models.DomainOrder, int, error)
+	GetOrder(ctx context.Context, userID int, orderID int) (models.DomainOrder, error)
+	GetOrderByID(ctx context.Context, orderID int) (models.DomainOrder, error)
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/AnatolyGolang/book-shop/config"
+	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minHMACKeyLength = 32
+
+type signingKey struct {
+	id         string
+	method     jwt.SigningMethod
+	signKey    interface{}
+	verifyKey  interface{}
+	publicKey  crypto.PublicKey
+	asymmetric bool
+}
+
+// KeySet signs tokens with the active key and verifies them with whichever configured key
+// their kid header names, so tokens signed before a rotation stay valid until they expire.
+type KeySet struct {
+	active *signingKey
+	keys   map[string]*signingKey
+	order  []string
+}
+
+func NewKeySet(activeID string, keys []config.JWTKey) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*signingKey, len(keys))}
+
+	for _, k := range keys {
+		if _, exists := set.keys[k.ID]; exists {
+			return nil, fmt.Errorf("duplicate jwt key id %v", k.ID)
+		}
+
+		key, err := parseSigningKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load jwt key %v: %w", k.ID, err)
+		}
+		set.keys[k.ID] = key
+		set.order = append(set.order, k.ID)
+	}
+
+	active, ok := set.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active jwt key %v is not configured", activeID)
+	}
+	set.active = active
+
+	return set, nil
+}
+
+func (s *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.active.method, claims)
+	token.Header["kid"] = s.active.id
+	return token.SignedString(s.active.signKey)
+}
+
+func (s *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, fmt.Errorf("missing kid header")
+	}
+
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %v", kid)
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("invalid singning method")
+	}
+	return key.verifyKey, nil
+}
+
+func (s *KeySet) algorithms() []string {
+	algs := make([]string, 0, len(s.keys))
+	seen := make(map[string]bool, len(s.keys))
+	for _, id := range s.order {
+		alg := s.keys[id].method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
+
+// PublicKeys returns the verification keys that can be published. HS256 secrets are never exposed.
+func (s *KeySet) PublicKeys() []sm.PublicKey {
+	var keys []sm.PublicKey
+	for _, id := range s.order {
+		key := s.keys[id]
+		if !key.asymmetric {
+			continue
+		}
+		keys = append(keys, sm.PublicKey{
+			ID:        key.id,
+			Algorithm: key.method.Alg(),
+			Key:       key.publicKey,
+		})
+	}
+	return keys
+}
+
+func parseSigningKey(k config.JWTKey) (*signingKey, error) {
+	switch k.Algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		if len(k.Material) < minHMACKeyLength {
+			return nil, fmt.Errorf("hmac secret must be at least %d bytes", minHMACKeyLength)
+		}
+		return &signingKey{
+			id:        k.ID,
+			method:    jwt.SigningMethodHS256,
+			signKey:   k.Material,
+			verifyKey: k.Material,
+		}, nil
+	case jwt.SigningMethodRS256.Alg():
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(k.Material)
+		if err != nil {
+			return nil, err
+		}
+		return &signingKey{
+			id:         k.ID,
+			method:     jwt.SigningMethodRS256,
+			signKey:    privateKey,
+			verifyKey:  &privateKey.PublicKey,
+			publicKey:  &privateKey.PublicKey,
+			asymmetric: true,
+		}, nil
+	case jwt.SigningMethodEdDSA.Alg():
+		privateKey, err := parseEd25519PrivateKey(k.Material)
+		if err != nil {
+			return nil, err
+		}
+		publicKey := privateKey.Public().(ed25519.PublicKey)
+		return &signingKey{
+			id:         k.ID,
+			method:     jwt.SigningMethodEdDSA,
+			signKey:    privateKey,
+			verifyKey:  publicKey,
+			publicKey:  publicKey,
+			asymmetric: true,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %v", k.Algorithm)
+	}
+}
+
+func parseEd25519PrivateKey(material []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(material)
+	if block == nil {
+		return nil, fmt.Errorf("key must be PEM encoded")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("key is not an ed25519 private key")
+	}
+	return privateKey, nil
+}
@@ -5,82 +5,268 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"time"
 
 	r "github.com/AnatolyGolang/book-shop/internal/app/repositories"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
 	sm "github.com/AnatolyGolang/book-shop/internal/app/services/models"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte("your_secret_key")
+const (
+	// activeTokenCacheTTL bounds how long a token revoked on another instance keeps working here.
+	activeTokenCacheTTL = 30 * time.Second
+)
 
+// Claims identifies the user by the sub claim and the session by jti, which is the key of the
+// token in user_tokens. IsAdmin is only a hint for clients: protected routes load the
+// permissions of the user instead of trusting it.
 type Claims struct {
-	Id      int    `json:"user_id"`
-	Email   string `json:"role"`
+	Email   string `json:"email"`
 	IsAdmin bool   `json:"is_admin"`
 	jwt.RegisteredClaims
 }
 
 type JWTServiceImpl struct {
-	repository r.TokenRepository
+	repository     r.TokenRepository
+	userRepository r.UserRepository
+	keys           *KeySet
+	cache          *tokenCache
+	issuer         string
+	audience       string
+	accessTTL      time.Duration
+	refreshTTL     time.Duration
 }
 
-func NewJWTService(repo r.TokenRepository) *JWTServiceImpl {
+func NewJWTService(repo r.TokenRepository, userRepo r.UserRepository, keys *KeySet, issuer, audience string, accessTTL, refreshTTL time.Duration) *JWTServiceImpl {
 	return &JWTServiceImpl{
-		repository: repo,
+		repository:     repo,
+		userRepository: userRepo,
+		keys:           keys,
+		issuer:         issuer,
+		audience:       audience,
+		cache:          newTokenCache(),
+		accessTTL:      accessTTL,
+		refreshTTL:     refreshTTL,
+	}
+}
+
+// IssueTokens starts a new session from device: a short-lived access token and a refresh token
+// that belong to a fresh token family.
+func (s *JWTServiceImpl) IssueTokens(ctx context.Context, user sm.DomainUser, device sm.Device) (sm.TokenPair, error) {
+	familyID, err := randomToken()
+	if err != nil {
+		return sm.TokenPair{}, err
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return sm.TokenPair{}, err
+	}
+	refreshExpiresAt := time.Now().Add(s.refreshTTL)
+
+	err = s.repository.SaveRefreshToken(ctx, user.Id, familyID, hashToken(refreshToken), refreshExpiresAt, device)
+	if err != nil {
+		return sm.TokenPair{}, err
+	}
+
+	accessToken, accessExpiresAt, err := s.generateJWT(ctx, user, familyID, device)
+	if err != nil {
+		return sm.TokenPair{}, err
+	}
+
+	return sm.TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// RefreshTokens rotates the refresh token and issues a new access token for the same session.
+// The user is reloaded so that the new access token carries the current role.
+func (s *JWTServiceImpl) RefreshTokens(ctx context.Context, refreshToken string) (sm.TokenPair, error) {
+	newRefreshToken, err := randomToken()
+	if err != nil {
+		return sm.TokenPair{}, err
+	}
+	refreshExpiresAt := time.Now().Add(s.refreshTTL)
+
+	userID, familyID, device, revoked, err := s.repository.RotateRefreshToken(ctx, hashToken(refreshToken), hashToken(newRefreshToken), refreshExpiresAt)
+	if errors.Is(err, se.ErrRefreshTokenReused) {
+		// The family was revoked because its token leaked; its access tokens must stop working at once.
+		s.MarkRevoked(revoked)
+	}
+	if err != nil {
+		return sm.TokenPair{}, err
+	}
+
+	user, err := s.userRepository.GetUserById(ctx, userID)
+	if err != nil {
+		return sm.TokenPair{}, err
+	}
+	if user.DisabledAt != nil {
+		return sm.TokenPair{}, se.ErrAccountDisabled
+	}
+
+	accessToken, accessExpiresAt, err := s.generateJWT(ctx, sm.ToDomainUser(user), familyID, device)
+	if err != nil {
+		return sm.TokenPair{}, err
 	}
+
+	return sm.TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     newRefreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
 }
 
-func (s *JWTServiceImpl) GenerateJWT(ctx context.Context, user sm.DomainUser) (string, error) {
-	expirationTime := time.Now().Add(1 * time.Hour)
+func (s *JWTServiceImpl) generateJWT(ctx context.Context, user sm.DomainUser, familyID string, device sm.Device) (string, time.Time, error) {
+	jti, err := randomToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expirationTime := now.Add(s.accessTTL)
 
 	claims := &Claims{
-		Id:      user.Id,
 		Email:   user.Email,
 		IsAdmin: user.IsAdmin,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.Id),
+			Issuer:    s.issuer,
+			Audience:  jwt.ClaimStrings{s.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			ID:        jti,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	signedToken, err := token.SignedString(jwtSecret)
+	signedToken, err := s.keys.sign(claims)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign token: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
 	}
 
-	err = s.repository.SaveToken(ctx, user.Id, signedToken, expirationTime)
+	err = s.repository.SaveToken(ctx, user.Id, jti, familyID, expirationTime, device)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
+	s.cache.set(jti, true, activeTokenCacheTTL)
 
-	return signedToken, nil
+	return signedToken, expirationTime, nil
 }
 
 func (s *JWTServiceImpl) GetUser(ctx context.Context, token string) (sm.DomainUser, error) {
-	var userClaims Claims
-	parsedJwt, err := jwt.ParseWithClaims(token, &userClaims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("invalid singning method")
-		}
-		return jwtSecret, nil
-	})
+	claims, err := s.parseClaims(token)
+	if err != nil {
+		return sm.DomainUser{}, err
+	}
 
+	active, err := s.isActive(ctx, claims.ID)
 	if err != nil {
-		return sm.DomainUser{}, fmt.Errorf("failed to parse token: %w", err)
+		return sm.DomainUser{}, err
+	}
+	if !active {
+		return sm.DomainUser{}, se.ErrTokenRevoked
+	}
+
+	return claimsToUser(claims)
+}
+
+func (s *JWTServiceImpl) RevokeToken(ctx context.Context, token string) error {
+	claims, err := s.parseClaims(token)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := s.repository.RevokeSession(ctx, claims.ID)
+	if err != nil {
+		return err
+	}
+	s.MarkRevoked(append(revoked, claims.ID))
+	return nil
+}
+
+func (s *JWTServiceImpl) GetSessions(ctx context.Context, userID int) ([]sm.DomainSession, error) {
+	sessions, err := s.repository.GetSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	domainSessions := make([]sm.DomainSession, 0, len(sessions))
+	for _, session := range sessions {
+		domainSessions = append(domainSessions, sm.ToDomainSession(session))
+	}
+	return domainSessions, nil
+}
+
+// RevokeSession ends one of the user's sessions, identified by an id from GetSessions.
+func (s *JWTServiceImpl) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	jtis, err := s.repository.RevokeUserSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	s.MarkRevoked(jtis)
+	return nil
+}
+
+// MarkRevoked caches the access tokens as revoked, so that tokens deleted from the token store
+// are rejected at once instead of when their cache entries expire.
+func (s *JWTServiceImpl) MarkRevoked(jtis []string) {
+	for _, jti := range jtis {
+		s.cache.set(jti, false, s.accessTTL)
+	}
+}
+
+func (s *JWTServiceImpl) parseClaims(token string) (Claims, error) {
+	var claims Claims
+	parsedJwt, err := jwt.ParseWithClaims(token, &claims, s.keys.keyFunc,
+		jwt.WithValidMethods(s.keys.algorithms()),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(s.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return Claims{}, fmt.Errorf("failed to parse token: %w", err)
 	}
 
 	if !parsedJwt.Valid {
-		return sm.DomainUser{}, errors.New("invalid token")
+		return Claims{}, errors.New("invalid token")
 	}
 
-	return claimsToUser(userClaims), nil
+	if claims.ID == "" {
+		return Claims{}, errors.New("token has no jti")
+	}
+	return claims, nil
 }
 
-func (s *JWTServiceImpl) RevokeToken(ctx context.Context, token string) error {
-	return s.repository.DeleteToken(ctx, token)
+func (s *JWTServiceImpl) PublicKeys() []sm.PublicKey {
+	return s.keys.PublicKeys()
+}
+
+// isActive checks that the token has not been revoked, consulting the token store only on a cache miss.
+func (s *JWTServiceImpl) isActive(ctx context.Context, jti string) (bool, error) {
+	if active, found := s.cache.get(jti); found {
+		return active, nil
+	}
+
+	active, err := s.repository.IsActive(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+
+	if active {
+		s.cache.set(jti, true, activeTokenCacheTTL)
+	} else {
+		s.cache.set(jti, false, s.accessTTL)
+	}
+	return active, nil
 }
 
 func (s *JWTServiceImpl) StartTokenCleanupScheduler() {
@@ -90,15 +276,21 @@ func (s *JWTServiceImpl) StartTokenCleanupScheduler() {
 			if err != nil {
 				log.Printf("failed to cleanup expired tokens: %v", err)
 			}
+			s.cache.evictExpired()
 			time.Sleep(1 * time.Minute)
 		}
 	}()
 }
 
-func claimsToUser(claims Claims) sm.DomainUser {
+func claimsToUser(claims Claims) (sm.DomainUser, error) {
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil || id <= 0 {
+		return sm.DomainUser{}, fmt.Errorf("invalid subject in token")
+	}
+
 	return sm.DomainUser{
-		Id:      claims.Id,
+		Id:      id,
 		Email:   claims.Email,
 		IsAdmin: claims.IsAdmin,
-	}
+	}, nil
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/AnatolyGolang/book-shop/internal/app/repositories/models"
+)
+
+type DomainAuditEntry struct {
+	ID           int
+	ActorID      *int
+	Action       string
+	TargetUserID *int
+	Details      map[string]any
+	CreatedAt    time.Time
+}
+
+func ToDomainAuditEntry(e models.AuditEntry) DomainAuditEntry {
+	return DomainAuditEntry{
+		ID:           e.ID,
+		ActorID:      e.ActorID,
+		Action:       e.Action,
+		TargetUserID: e.TargetUserID,
+		Details:      e.Details,
+		CreatedAt:    e.CreatedAt,
+	}
+}
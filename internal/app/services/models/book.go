@@ -31,3 +31,19 @@ func ToDomainBook(b models.Book) DomainBook {
 		UpdatedAt:  b.UpdatedAt,
 	}
 }
+
+type DomainBookSearchResult struct {
+	Book            DomainBook
+	Rank            float64
+	TitleHighlight  string
+	AuthorHighlight string
+}
+
+func ToDomainBookSearchResult(r models.BookSearchResult) DomainBookSearchResult {
+	return DomainBookSearchResult{
+		Book:            ToDomainBook(r.Book),
+		Rank:            r.Rank,
+		TitleHighlight:  r.TitleHighlight,
+		AuthorHighlight: r.AuthorHighlight,
+	}
+}
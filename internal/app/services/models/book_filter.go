@@ -0,0 +1,65 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	BookSortID     = "id"
+	BookSortPrice  = "price"
+	BookSortYear   = "year"
+	BookSortTitle  = "title"
+	BookSortNewest = "newest"
+)
+
+// BookFilter describes a catalog listing. Every criterion is optional; zero values and nil
+// pointers mean "do not filter".
+type BookFilter struct {
+	CategoryIDs    []int
+	Author         string
+	Title          string
+	YearFrom       *int
+	YearTo         *int
+	PriceFrom      *int
+	PriceTo        *int
+	InStockOnly    bool
+	IncludeSoldOut bool
+	SortBy         string
+	Descending     bool
+	Limit          int
+	Offset         int
+}
+
+// BookCursor marks a position in a listing for keyset pagination: the sort key and id of the
+// row on the edge of a page. Backward cursors fetch the page before that row.
+type BookCursor struct {
+	SortBy     string
+	Descending bool
+	Key        string
+	ID         int
+	Backward   bool
+}
+
+// NewBookCursor builds the cursor pointing at book in a listing ordered by the filter's sort.
+func NewBookCursor(filter BookFilter, book DomainBook, backward bool) BookCursor {
+	var key string
+	switch filter.SortBy {
+	case BookSortPrice:
+		key = strconv.Itoa(book.Price)
+	case BookSortYear:
+		key = strconv.Itoa(book.Year)
+	case BookSortTitle:
+		key = book.Title
+	case BookSortNewest:
+		key = book.CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	return BookCursor{
+		SortBy:     filter.SortBy,
+		Descending: filter.Descending,
+		Key:        key,
+		ID:         book.ID,
+		Backward:   backward,
+	}
+}
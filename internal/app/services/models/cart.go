@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type DomainCart struct {
+	Items      []DomainCartLine
+	TotalPrice int
+}
+
+type DomainCartLine struct {
+	Book       DomainBook
+	Quantity   int
+	ReservedAt time.Time
+	ExpiresAt  time.Time
+}
+
+type DomainCartItem struct {
+	BookID   int
+	Quantity int
+}
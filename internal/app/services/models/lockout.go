@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/AnatolyGolang/book-shop/internal/app/repositories/models"
+)
+
+// LockoutPolicy locks an account or an IP once it reaches its limit of failed sign-ins. Every
+// further failure doubles the lockout, up to MaxLockout. Counters start over once no failure
+// has happened for MaxLockout.
+type LockoutPolicy struct {
+	MaxAccountAttempts int
+	MaxIPAttempts      int
+	BaseLockout        time.Duration
+	MaxLockout         time.Duration
+}
+
+func (p LockoutPolicy) LockoutFor(failures int, maxAttempts int) time.Duration {
+	if failures < maxAttempts {
+		return 0
+	}
+
+	lockout := p.BaseLockout
+	for i := maxAttempts; i < failures && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, p.MaxLockout)
+}
+
+type DomainLockout struct {
+	FailedAttempts int
+	LastFailedAt   *time.Time
+	LockedUntil    *time.Time
+}
+
+func ToDomainLockout(t models.LoginThrottle) DomainLockout {
+	lockout := DomainLockout{
+		FailedAttempts: t.FailedAttempts,
+		LockedUntil:    t.LockedUntil,
+	}
+	if !t.LastFailedAt.IsZero() {
+		lockout.LastFailedAt = &t.LastFailedAt
+	}
+	return lockout
+}
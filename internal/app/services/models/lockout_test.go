@@ -0,0 +1,48 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutPolicyLockoutFor(t *testing.T) {
+	policy := LockoutPolicy{
+		MaxAccountAttempts: 5,
+		MaxIPAttempts:      20,
+		BaseLockout:        time.Minute,
+		MaxLockout:         10 * time.Minute,
+	}
+
+	tests := []struct {
+		name        string
+		failures    int
+		maxAttempts int
+		want        time.Duration
+	}{
+		{name: "no failures", failures: 0, maxAttempts: 5, want: 0},
+		{name: "below the limit", failures: 4, maxAttempts: 5, want: 0},
+		{name: "at the limit", failures: 5, maxAttempts: 5, want: time.Minute},
+		{name: "one over the limit doubles", failures: 6, maxAttempts: 5, want: 2 * time.Minute},
+		{name: "three over the limit", failures: 8, maxAttempts: 5, want: 8 * time.Minute},
+		{name: "capped at the maximum", failures: 9, maxAttempts: 5, want: 10 * time.Minute},
+		{name: "far over the limit stays capped", failures: 1000, maxAttempts: 5, want: 10 * time.Minute},
+		{name: "ip limit below", failures: 19, maxAttempts: 20, want: 0},
+		{name: "ip limit reached", failures: 20, maxAttempts: 20, want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.LockoutFor(tt.failures, tt.maxAttempts); got != tt.want {
+				t.Errorf("LockoutFor(%d, %d) = %v, want %v", tt.failures, tt.maxAttempts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockoutPolicyLockoutForBaseAboveMax(t *testing.T) {
+	policy := LockoutPolicy{BaseLockout: time.Hour, MaxLockout: time.Minute}
+
+	if got := policy.LockoutFor(3, 3); got != time.Minute {
+		t.Errorf("LockoutFor() = %v, want the maximum %v", got, time.Minute)
+	}
+}
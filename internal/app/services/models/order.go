@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/AnatolyGolang/book-shop/internal/app/repositories/models"
+)
+
+type DomainOrder struct {
+	ID         int
+	UserID     int
+	TotalPrice int
+	CreatedAt  time.Time
+	Items      []DomainOrderItem
+}
+
+type DomainOrderItem struct {
+	ID       int
+	BookID   *int
+	Title    string
+	Author   string
+	Price    int
+	Quantity int
+}
+
+func ToDomainOrder(o models.Order) DomainOrder {
+	items := make([]DomainOrderItem, len(o.Items))
+	for i, item := range o.Items {
+		items[i] = DomainOrderItem{
+			ID:       item.ID,
+			BookID:   item.BookID,
+			Title:    item.Title,
+			Author:   item.Author,
+			Price:    item.Price,
+			Quantity: item.Quantity,
+		}
+	}
+
+	return DomainOrder{
+		ID:         o.ID,
+		UserID:     o.UserID,
+		TotalPrice: o.TotalPrice,
+		CreatedAt:  o.CreatedAt,
+		Items:      items,
+	}
+}
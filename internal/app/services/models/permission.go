@@ -0,0 +1,38 @@
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AnatolyGolang/book-shop/internal/app/utils"
+)
+
+const (
+	PermissionBooksWrite      = "books:write"
+	PermissionCategoriesWrite = "categories:write"
+	PermissionStockRead       = "stock:read"
+	PermissionOrdersRead      = "orders:read"
+	PermissionUsersManage     = "users:manage"
+)
+
+type Permissions map[string]bool
+
+func NewPermissions(names []string) Permissions {
+	permissions := make(Permissions, len(names))
+	for _, name := range names {
+		permissions[name] = true
+	}
+	return permissions
+}
+
+func (p Permissions) Has(permission string) bool {
+	return p[permission]
+}
+
+func GetPermissionsFromContext(ctx context.Context) (Permissions, error) {
+	permissions, ok := ctx.Value(utils.ContextPermissionsKey).(Permissions)
+	if !ok {
+		return nil, fmt.Errorf("no permissions in context")
+	}
+	return permissions, nil
+}
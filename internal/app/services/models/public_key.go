@@ -0,0 +1,9 @@
+package models
+
+import "crypto"
+
+type PublicKey struct {
+	ID        string
+	Algorithm string
+	Key       crypto.PublicKey
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/AnatolyGolang/book-shop/internal/app/repositories/models"
+)
+
+type DomainStockMovement struct {
+	ID        int
+	BookID    int
+	Delta     int
+	Reason    string
+	UserID    *int
+	Note      *string
+	CreatedAt time.Time
+}
+
+func ToDomainStockMovement(m models.StockMovement) DomainStockMovement {
+	return DomainStockMovement{
+		ID:        m.ID,
+		BookID:    m.BookID,
+		Delta:     m.Delta,
+		Reason:    m.Reason,
+		UserID:    m.UserID,
+		Note:      m.Note,
+		CreatedAt: m.CreatedAt,
+	}
+}
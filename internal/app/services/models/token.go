@@ -0,0 +1,52 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/AnatolyGolang/book-shop/internal/app/repositories/models"
+)
+
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+const maxUserAgentLength = 512
+
+// Device describes the client a session was started from.
+type Device struct {
+	UserAgent string
+	IP        string
+}
+
+// NewDevice cuts the user agent to a sane length, since it is whatever the client sends.
+func NewDevice(userAgent string, ip string) Device {
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+	return Device{UserAgent: userAgent, IP: ip}
+}
+
+type DomainSession struct {
+	ID         string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+	Device     Device
+}
+
+func ToDomainSession(s models.Session) DomainSession {
+	return DomainSession{
+		ID:         s.ID,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+		ExpiresAt:  s.ExpiresAt,
+		Device: Device{
+			UserAgent: s.UserAgent,
+			IP:        s.IP,
+		},
+	}
+}
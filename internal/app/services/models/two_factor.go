@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// TOTPEnrollment is what the user adds to an authenticator app: Secret to type in by hand, or
+// URI to scan as a QR code.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// TwoFactorChallenge is handed out when the password was right but a code is still needed.
+type TwoFactorChallenge struct {
+	Token     string
+	ExpiresAt time.Time
+}
@@ -3,32 +3,95 @@ package models
 import (
 	"context"
 	"fmt"
+	"net/mail"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/AnatolyGolang/book-shop/internal/app/repositories/models"
 	"github.com/AnatolyGolang/book-shop/internal/app/utils"
 )
 
 type DomainUser struct {
-	Id        int
-	Email     string
-	Password  string
-	IsAdmin   bool
-	CreatedAt time.Time
-	UpdatedAt *time.Time
+	Id              int
+	Email           string
+	Password        string
+	DisplayName     *string
+	IsAdmin         bool
+	DisabledAt      *time.Time
+	EmailVerifiedAt *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       *time.Time
 }
 
 func ToDomainUser(u models.User) DomainUser {
 	return DomainUser{
-		Id:        u.Id,
-		Email:     u.Email,
-		Password:  u.Password,
-		IsAdmin:   u.IsAdmin,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		Id:              u.Id,
+		Email:           u.Email,
+		Password:        u.Password,
+		DisplayName:     u.DisplayName,
+		IsAdmin:         u.IsAdmin,
+		DisabledAt:      u.DisabledAt,
+		EmailVerifiedAt: u.EmailVerifiedAt,
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
 	}
 }
 
+func (u DomainUser) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+func (u DomainUser) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+const (
+	maxEmailLength       = 254
+	maxDisplayNameLength = 100
+)
+
+// ProfileUpdate holds the profile fields a user changes; nil fields are left as they are.
+type ProfileUpdate struct {
+	DisplayName *string
+}
+
+// NormalizeDisplayName trims name and returns nil if nothing is left, which clears the name.
+func NormalizeDisplayName(name string) (*string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+	if utf8.RuneCountInString(name) > maxDisplayNameLength {
+		return nil, fmt.Errorf("must be at most %d characters long", maxDisplayNameLength)
+	}
+	if strings.ContainsFunc(name, unicode.IsControl) {
+		return nil, fmt.Errorf("must not contain control characters")
+	}
+	return &name, nil
+}
+
+// CanonicalEmail is the form in which emails are stored and looked up.
+func CanonicalEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizeEmail checks that email is a bare RFC 5322 address, without a display name or angle
+// brackets, and returns its canonical form.
+func NormalizeEmail(email string) (string, error) {
+	email = CanonicalEmail(email)
+	if len(email) > maxEmailLength {
+		return "", fmt.Errorf("must be at most %d characters long", maxEmailLength)
+	}
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Name != "" || address.Address != email {
+		return "", fmt.Errorf("is not a valid email address")
+	}
+	return email, nil
+}
+
 func GetUserFromContext(ctx context.Context) (DomainUser, error) {
 	contextUser := ctx.Value(utils.ContextUserKey)
 	if contextUser == nil {
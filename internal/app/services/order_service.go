@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+
+	r "github.com/AnatolyGolang/book-shop/internal/app/repositories"
+	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
+)
+
+type OrderServiceImpl struct {
+	repository r.OrderRepository
+}
+
+func NewOrderService(repo r.OrderRepository) *OrderServiceImpl {
+	return &OrderServiceImpl{
+		repository: repo,
+	}
+}
+
+func (s *OrderServiceImpl) GetOrders(ctx context.Context, userID int, limit int, offset int) ([]models.DomainOrder, int, error) {
+	orders, total, err := s.repository.GetOrders(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var domainOrders []models.DomainOrder
+	for _, order := range orders {
+		domainOrders = append(domainOrders, models.ToDomainOrder(order))
+	}
+
+	return domainOrders, total, nil
+}
+
+func (s *OrderServiceImpl) GetOrder(ctx context.Context, userID int, orderID int) (models.DomainOrder, error) {
+	order, err := s.repository.GetOrder(ctx, userID, orderID)
+	if err != nil {
+		return models.DomainOrder{}, err
+	}
+	return models.ToDomainOrder(order), nil
+}
+
+func (s *OrderServiceImpl) GetOrderByID(ctx context.Context, orderID int) (models.DomainOrder, error) {
+	order, err := s.repository.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return models.DomainOrder{}, err
+	}
+	return models.ToDomainOrder(order), nil
+}
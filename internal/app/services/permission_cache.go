@@ -0,0 +1,58 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// permissionCache keeps the permissions of recently seen users so that protected routes do
+// not read the roles of the user on every request.
+type permissionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[int]permissionCacheEntry
+}
+
+type permissionCacheEntry struct {
+	permissions []string
+	expiresAt   time.Time
+}
+
+func newPermissionCache(ttl time.Duration) *permissionCache {
+	return &permissionCache{ttl: ttl, entries: make(map[int]permissionCacheEntry)}
+}
+
+func (c *permissionCache) get(userID int) (permissions []string, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[userID]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, userID)
+		return nil, false
+	}
+	return entry.permissions, true
+}
+
+func (c *permissionCache) set(userID int, permissions []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[userID] = permissionCacheEntry{permissions: permissions, expiresAt: time.Now().Add(c.ttl)}
+}
+
+func (c *permissionCache) evictExpired() {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for userID, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, userID)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	r "github.com/AnatolyGolang/book-shop/internal/app/repositories"
+	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
+)
+
+// permissionCacheTTL bounds how long a user keeps a permission after losing the role that granted it.
+const permissionCacheTTL = 30 * time.Second
+
+type RoleServiceImpl struct {
+	repository r.RoleRepository
+	cache      *permissionCache
+}
+
+func NewRoleService(repo r.RoleRepository) *RoleServiceImpl {
+	return &RoleServiceImpl{
+		repository: repo,
+		cache:      newPermissionCache(permissionCacheTTL),
+	}
+}
+
+func (s *RoleServiceImpl) GetPermissions(ctx context.Context, userID int) (models.Permissions, error) {
+	if permissions, found := s.cache.get(userID); found {
+		return models.NewPermissions(permissions), nil
+	}
+
+	permissions, err := s.repository.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.set(userID, permissions)
+	return models.NewPermissions(permissions), nil
+}
+
+// PermissionCacheCleanupScheduler drops expired permissions, which are otherwise only evicted
+// when the same user is seen again.
+func (s *RoleServiceImpl) PermissionCacheCleanupScheduler() {
+	go func() {
+		for {
+			s.cache.evictExpired()
+			time.Sleep(1 * time.Minute)
+		}
+	}()
+}
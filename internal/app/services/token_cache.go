@@ -0,0 +1,62 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenCache remembers whether a token is still present in the token store, so that
+// authenticated requests do not hit the database every time. Entries are keyed by the
+// hash of the token's jti and evicted once their TTL passes.
+type tokenCache struct {
+	mu      sync.Mutex
+	entries map[string]tokenCacheEntry
+}
+
+type tokenCacheEntry struct {
+	active    bool
+	expiresAt time.Time
+}
+
+func newTokenCache() *tokenCache {
+	return &tokenCache{entries: make(map[string]tokenCacheEntry)}
+}
+
+func (c *tokenCache) get(token string) (active bool, found bool) {
+	key := hashToken(token)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return false, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return false, false
+	}
+	return entry.active, true
+}
+
+func (c *tokenCache) set(token string, active bool, ttl time.Duration) {
+	key := hashToken(token)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = tokenCacheEntry{active: active, expiresAt: time.Now().Add(ttl)}
+}
+
+func (c *tokenCache) evictExpired() {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	r "github.com/AnatolyGolang/book-shop/internal/app/repositories"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
+	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
+	"github.com/AnatolyGolang/book-shop/internal/pkg/totp"
+)
+
+const (
+	// totpSkew accepts the codes of the previous and the next period, for clocks that drift.
+	totpSkew             = 1
+	maxChallengeAttempts = 5
+	recoveryCodeCount    = 10
+	recoveryCodeLength   = 10
+)
+
+type TwoFactorServiceImpl struct {
+	repository              r.TwoFactorRepository
+	userRepository          r.UserRepository
+	loginThrottleRepository r.LoginThrottleRepository
+	secrets                 SecretBox
+	tokenRevoker            TokenRevoker
+	issuer                  string
+	challengeTTL            time.Duration
+	lockoutPolicy           models.LockoutPolicy
+}
+
+func NewTwoFactorService(repo r.TwoFactorRepository, userRepo r.UserRepository, throttleRepo r.LoginThrottleRepository,
+	secrets SecretBox, tokenRevoker TokenRevoker, issuer string, challengeTTL time.Duration,
+	policy models.LockoutPolicy) *TwoFactorServiceImpl {
+	return &TwoFactorServiceImpl{
+		repository:              repo,
+		userRepository:          userRepo,
+		loginThrottleRepository: throttleRepo,
+		secrets:                 secrets,
+		tokenRevoker:            tokenRevoker,
+		issuer:                  issuer,
+		challengeTTL:            challengeTTL,
+		lockoutPolicy:           policy,
+	}
+}
+
+// BeginEnrollment creates a new TOTP secret for the user. Two-factor authentication is only
+// turned on once ConfirmEnrollment gets a code generated from it.
+func (s *TwoFactorServiceImpl) BeginEnrollment(ctx context.Context, userID int) (models.TOTPEnrollment, error) {
+	user, err := s.userRepository.GetUserById(ctx, userID)
+	if err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+
+	sealed, err := s.secrets.Seal(secret, totpAdditionalData(userID))
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("failed to encrypt totp secret: %w", err)
+	}
+
+	if err := s.repository.SaveTOTPSecret(ctx, userID, sealed); err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+
+	return models.TOTPEnrollment{
+		Secret: totp.EncodeSecret(secret),
+		URI:    totp.URI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmEnrollment turns two-factor authentication on and returns the recovery codes, which
+// are not stored in plain text and can not be shown again. All sessions of the user end.
+func (s *TwoFactorServiceImpl) ConfirmEnrollment(ctx context.Context, userID int, code string) ([]string, error) {
+	userTOTP, err := s.repository.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, se.ErrNotFound) {
+			return nil, se.ErrTwoFactorNotEnabled
+		}
+		return nil, err
+	}
+	if userTOTP.ConfirmedAt != nil {
+		return nil, se.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := s.secrets.Open(userTOTP.Secret, totpAdditionalData(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	step, ok := totp.Validate(secret, normalizeCode(code), time.Now(), totpSkew)
+	if !ok {
+		return nil, se.ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.repository.ConfirmTOTP(ctx, userID, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	s.tokenRevoker.MarkRevoked(revoked)
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off after checking a code or a recovery code.
+// Admins can not turn it off.
+func (s *TwoFactorServiceImpl) Disable(ctx context.Context, userID int, code string) error {
+	state, err := s.repository.GetTwoFactorState(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !state.Enabled {
+		return se.ErrTwoFactorNotEnabled
+	}
+	if state.IsAdmin {
+		return se.ErrTwoFactorRequired
+	}
+
+	ok, err := s.verifyCode(ctx, userID, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return se.ErrInvalidTwoFactorCode
+	}
+
+	return s.repository.DisableTOTP(ctx, userID)
+}
+
+func (s *TwoFactorServiceImpl) IsEnabled(ctx context.Context, userID int) (bool, error) {
+	state, err := s.repository.GetTwoFactorState(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return state.Enabled, nil
+}
+
+// CheckRequirement returns ErrTwoFactorRequired for admins that have not turned two-factor
+// authentication on yet.
+func (s *TwoFactorServiceImpl) CheckRequirement(ctx context.Context, userID int) error {
+	state, err := s.repository.GetTwoFactorState(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if state.IsAdmin && !state.Enabled {
+		return se.ErrTwoFactorRequired
+	}
+	return nil
+}
+
+// StartChallenge is the first step of signing in with two-factor authentication, once the
+// password has been checked.
+func (s *TwoFactorServiceImpl) StartChallenge(ctx context.Context, userID int) (models.TwoFactorChallenge, error) {
+	token, err := randomToken()
+	if err != nil {
+		return models.TwoFactorChallenge{}, err
+	}
+	expiresAt := time.Now().Add(s.challengeTTL)
+
+	if err := s.repository.CreateChallenge(ctx, userID, hashToken(token), expiresAt); err != nil {
+		return models.TwoFactorChallenge{}, err
+	}
+	return models.TwoFactorChallenge{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// CompleteChallenge is the second step of signing in, for a request made from ip: it checks the
+// code, or a recovery code, and returns the user to issue tokens for. A challenge allows a few
+// wrong codes only, and wrong codes also count towards the lockout of the account and the IP,
+// so that starting new challenges with the password does not allow guessing without end.
+func (s *TwoFactorServiceImpl) CompleteChallenge(ctx context.Context, challengeToken string, code string, ip string) (models.DomainUser, error) {
+	challengeID, userID, err := s.repository.AttemptChallenge(ctx, hashToken(challengeToken), maxChallengeAttempts)
+	if err != nil {
+		return models.DomainUser{}, err
+	}
+
+	user, err := s.userRepository.GetUserById(ctx, userID)
+	if err != nil {
+		return models.DomainUser{}, err
+	}
+	account := loginAccountKey(user.Email)
+
+	lockedUntil, err := s.loginThrottleRepository.GetLockedUntil(ctx, account, ip)
+	if err != nil {
+		return models.DomainUser{}, err
+	}
+	if lockedUntil != nil {
+		return models.DomainUser{}, se.NewLockedError(*lockedUntil)
+	}
+
+	ok, err := s.verifyCode(ctx, userID, code)
+	if err != nil {
+		return models.DomainUser{}, err
+	}
+	if !ok {
+		err := s.loginThrottleRepository.RecordTwoFactorFailure(ctx, account, ip, s.lockoutPolicy)
+		if err != nil {
+			return models.DomainUser{}, err
+		}
+		return models.DomainUser{}, se.ErrInvalidTwoFactorCode
+	}
+
+	if err := s.repository.CompleteChallenge(ctx, challengeID); err != nil {
+		return models.DomainUser{}, err
+	}
+
+	if err := s.loginThrottleRepository.ResetTwoFactor(ctx, account); err != nil {
+		return models.DomainUser{}, err
+	}
+
+	domainUser := models.ToDomainUser(user)
+	if domainUser.IsDisabled() {
+		return models.DomainUser{}, se.ErrAccountDisabled
+	}
+	return domainUser, nil
+}
+
+func (s *TwoFactorServiceImpl) ChallengeCleanupScheduler() {
+	go func() {
+		for {
+			err := s.repository.CleanupExpiredChallenges(context.Background())
+			if err != nil {
+				log.Printf("failed to cleanup two-factor challenges: %v", err)
+			}
+			time.Sleep(10 * time.Minute)
+		}
+	}()
+}
+
+// verifyCode accepts a current TOTP code that was not used before, or an unused recovery code.
+func (s *TwoFactorServiceImpl) verifyCode(ctx context.Context, userID int, code string) (bool, error) {
+	code = normalizeCode(code)
+	if len(code) != totp.Digits {
+		return s.repository.UseRecoveryCode(ctx, userID, hashToken(code))
+	}
+
+	userTOTP, err := s.repository.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, se.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if userTOTP.ConfirmedAt == nil {
+		return false, nil
+	}
+
+	secret, err := s.secrets.Open(userTOTP.Secret, totpAdditionalData(userID))
+	if err != nil {
+		return false, fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return false, nil
+	}
+	return s.repository.UseTOTPStep(ctx, userID, step)
+}
+
+// normalizeCode drops the separators users type or copy along with codes.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+// generateRecoveryCodes returns codes formatted for people, like "abcde-fghij", and the hashes
+// of their normalised form for storage.
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, recoveryCodeLength*5/8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		code := strings.ToLower(encoding.EncodeToString(b))
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hashes[i] = hashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+// totpAdditionalData binds an encrypted secret to its user, so that it can not be copied to
+// another account.
+func totpAdditionalData(userID int) []byte {
+	return []byte("user_totp:" + strconv.Itoa(userID))
+}
@@ -2,31 +2,211 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
 
 	r "github.com/AnatolyGolang/book-shop/internal/app/repositories"
+	se "github.com/AnatolyGolang/book-shop/internal/app/services/errors"
 	"github.com/AnatolyGolang/book-shop/internal/app/services/models"
 )
 
 type UserServiceImpl struct {
-	repository r.UserRepository
+	repository              r.UserRepository
+	auditRepository         r.AuditRepository
+	loginThrottleRepository r.LoginThrottleRepository
+	passwordHasher          PasswordHasher
+	passwordPolicy          PasswordPolicy
+	lockoutPolicy           models.LockoutPolicy
+	tokenRevoker            TokenRevoker
+	// dummyHash is compared against when the email is unknown, so that such a sign-in
+	// takes as long as one with a wrong password.
+	dummyHash string
 }
 
-func NewUserService(repo r.UserRepository) *UserServiceImpl {
+func NewUserService(repo r.UserRepository, auditRepo r.AuditRepository, throttleRepo r.LoginThrottleRepository,
+	hasher PasswordHasher, passwordPolicy PasswordPolicy, policy models.LockoutPolicy, tokenRevoker TokenRevoker) *UserServiceImpl {
+	dummyHash, err := hasher.Hash("dummy-password")
+	if err != nil {
+		log.Printf("failed to generate dummy password hash: %v", err)
+	}
+
 	return &UserServiceImpl{
-		repository: repo,
+		repository:              repo,
+		auditRepository:         auditRepo,
+		loginThrottleRepository: throttleRepo,
+		passwordHasher:          hasher,
+		passwordPolicy:          passwordPolicy,
+		lockoutPolicy:           policy,
+		tokenRevoker:            tokenRevoker,
+		dummyHash:               dummyHash,
+	}
+}
+
+// Authenticate checks the credentials of a sign-in attempt made from ip. Unknown emails and
+// wrong passwords both fail with ErrInvalidCredentials, and both count towards the lockout
+// of the account and the IP.
+func (s *UserServiceImpl) Authenticate(ctx context.Context, email string, password string, ip string) (models.DomainUser, error) {
+	account := loginAccountKey(email)
+
+	lockedUntil, err := s.loginThrottleRepository.GetLockedUntil(ctx, account, ip)
+	if err != nil {
+		return models.DomainUser{}, err
+	}
+	if lockedUntil != nil {
+		return models.DomainUser{}, se.NewLockedError(*lockedUntil)
+	}
+
+	user, err := s.repository.GetUserByEmail(ctx, account)
+	if err != nil && !errors.Is(err, se.ErrNotFound) {
+		return models.DomainUser{}, err
+	}
+
+	if err != nil {
+		_, _, _ = s.passwordHasher.Verify(password, s.dummyHash)
+		return models.DomainUser{}, s.recordLoginFailure(ctx, account, ip)
+	}
+
+	ok, rehash := verifyPassword(s.passwordHasher, user.Id, password, user.Password)
+	if !ok {
+		return models.DomainUser{}, s.recordLoginFailure(ctx, account, ip)
+	}
+	if rehash {
+		s.rehashPassword(ctx, user.Id, password, user.Password)
+	}
+
+	if err := s.loginThrottleRepository.ResetAccount(ctx, account); err != nil {
+		return models.DomainUser{}, err
+	}
+
+	domainUser := models.ToDomainUser(user)
+	if domainUser.IsDisabled() {
+		return models.DomainUser{}, se.ErrAccountDisabled
+	}
+	return domainUser, nil
+}
+
+// rehashPassword upgrades an outdated hash. A failure only means the upgrade is retried on the
+// next sign-in, so it is logged instead of failing the sign-in.
+func (s *UserServiceImpl) rehashPassword(ctx context.Context, userID int, password string, oldHash string) {
+	newHash, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		log.Printf("failed to rehash password of user %d: %v", userID, err)
+		return
+	}
+
+	if err := s.repository.RehashPassword(ctx, userID, oldHash, newHash); err != nil {
+		log.Printf("failed to rehash password of user %d: %v", userID, err)
+	}
+}
+
+func (s *UserServiceImpl) recordLoginFailure(ctx context.Context, account string, ip string) error {
+	err := s.loginThrottleRepository.RecordFailure(ctx, account, ip, s.lockoutPolicy)
+	if err != nil {
+		return err
+	}
+	return se.ErrInvalidCredentials
+}
+
+func (s *UserServiceImpl) GetLockout(ctx context.Context, userID int) (models.DomainLockout, error) {
+	user, err := s.repository.GetUserById(ctx, userID)
+	if err != nil {
+		return models.DomainLockout{}, err
+	}
+
+	throttle, err := s.loginThrottleRepository.GetAccountThrottle(ctx, loginAccountKey(user.Email))
+	if err != nil {
+		return models.DomainLockout{}, err
+	}
+	return models.ToDomainLockout(throttle), nil
+}
+
+func (s *UserServiceImpl) UnlockUser(ctx context.Context, userID int) error {
+	actor, err := models.GetUserFromContext(ctx)
+	if err != nil {
+		return se.ErrNoUserInContext
+	}
+
+	user, err := s.repository.GetUserById(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.loginThrottleRepository.UnlockAccount(ctx, actor.Id, userID, loginAccountKey(user.Email))
+}
+
+func (s *UserServiceImpl) LoginThrottleCleanupScheduler() {
+	go func() {
+		for {
+			cutoff := time.Now().Add(-s.lockoutPolicy.MaxLockout)
+			err := s.loginThrottleRepository.CleanupStaleThrottles(context.Background(), cutoff)
+			if err != nil {
+				log.Printf("failed to cleanup login failures: %v", err)
+			}
+			time.Sleep(10 * time.Minute)
+		}
+	}()
+}
+
+func loginAccountKey(email string) string {
+	return models.CanonicalEmail(email)
+}
+
+func (s *UserServiceImpl) UpdateProfile(ctx context.Context, userID int, update models.ProfileUpdate) (models.DomainUser, error) {
+	if update.DisplayName == nil {
+		return s.GetUserById(ctx, userID)
+	}
+
+	displayName, err := models.NormalizeDisplayName(*update.DisplayName)
+	if err != nil {
+		validationErr := &se.ValidationError{}
+		validationErr.Add("display_name", "invalid-display-name", err.Error())
+		return models.DomainUser{}, validationErr
+	}
+
+	user, err := s.repository.SetDisplayName(ctx, userID, displayName)
+	if err != nil {
+		return models.DomainUser{}, err
 	}
+	return models.ToDomainUser(user), nil
 }
 
+// CreateUser stores a new user. domainUser.Password is the plain password; only its hash is saved.
+// Invalid fields are reported together in a *se.ValidationError.
 func (s *UserServiceImpl) CreateUser(ctx context.Context, domainUser models.DomainUser) (models.DomainUser, error) {
+	validationErr := &se.ValidationError{}
+
+	email, err := models.NormalizeEmail(domainUser.Email)
+	if err != nil {
+		validationErr.Add("email", "invalid-email", err.Error())
+	}
+	checkPassword(s.passwordPolicy, "password", domainUser.Password, validationErr)
+
+	if err := validationErr.Err(); err != nil {
+		return models.DomainUser{}, err
+	}
+	domainUser.Email = email
+
+	passwordHash, err := s.passwordHasher.Hash(domainUser.Password)
+	if err != nil {
+		return models.DomainUser{}, fmt.Errorf("failed to hash password: %w", err)
+	}
+	domainUser.Password = passwordHash
+
 	book, err := s.repository.CreateUser(ctx, domainUser)
 	if err != nil {
+		if errors.Is(err, se.ErrEmailTaken) {
+			validationErr.Add("email", "email-taken", "an account with this email already exists")
+			return models.DomainUser{}, validationErr
+		}
 		return models.DomainUser{}, err
 	}
 	return models.ToDomainUser(book), nil
 }
 
 func (s *UserServiceImpl) GetUserByName(ctx context.Context, name string) (models.DomainUser, error) {
-	user, err := s.repository.GetUserByEmail(ctx, name)
+	user, err := s.repository.GetUserByEmail(ctx, models.CanonicalEmail(name))
 	if err != nil {
 		return models.DomainUser{}, err
 	}
@@ -40,3 +220,98 @@ func (s *UserServiceImpl) GetUserById(ctx context.Context, id int) (models.Domai
 	}
 	return models.ToDomainUser(user), nil
 }
+
+func (s *UserServiceImpl) GetUsers(ctx context.Context, search string, limit int, offset int) ([]models.DomainUser, int, error) {
+	users, total, err := s.repository.GetUsers(ctx, search, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var domainUsers []models.DomainUser
+	for _, user := range users {
+		domainUsers = append(domainUsers, models.ToDomainUser(user))
+	}
+
+	return domainUsers, total, nil
+}
+
+// SetAdmin grants or revokes the admin role. Admins can not revoke their own role, so that
+// the last admin can not lock everyone out by accident.
+func (s *UserServiceImpl) SetAdmin(ctx context.Context, userID int, isAdmin bool) (models.DomainUser, error) {
+	actor, err := models.GetUserFromContext(ctx)
+	if err != nil {
+		return models.DomainUser{}, se.ErrNoUserInContext
+	}
+	if !isAdmin && actor.Id == userID {
+		return models.DomainUser{}, se.ErrSelfAction
+	}
+
+	user, err := s.repository.SetAdmin(ctx, actor.Id, userID, isAdmin)
+	if err != nil {
+		return models.DomainUser{}, err
+	}
+	return models.ToDomainUser(user), nil
+}
+
+func (s *UserServiceImpl) SetDisabled(ctx context.Context, userID int, disabled bool) (models.DomainUser, error) {
+	actor, err := models.GetUserFromContext(ctx)
+	if err != nil {
+		return models.DomainUser{}, se.ErrNoUserInContext
+	}
+	if disabled && actor.Id == userID {
+		return models.DomainUser{}, se.ErrSelfAction
+	}
+
+	user, revoked, err := s.repository.SetDisabled(ctx, actor.Id, userID, disabled)
+	if err != nil {
+		return models.DomainUser{}, err
+	}
+	s.tokenRevoker.MarkRevoked(revoked)
+	return models.ToDomainUser(user), nil
+}
+
+func (s *UserServiceImpl) ForceLogout(ctx context.Context, userID int) (int, error) {
+	actor, err := models.GetUserFromContext(ctx)
+	if err != nil {
+		return 0, se.ErrNoUserInContext
+	}
+
+	revoked, err := s.repository.ForceLogout(ctx, actor.Id, userID)
+	if err != nil {
+		return 0, err
+	}
+	s.tokenRevoker.MarkRevoked(revoked)
+	return len(revoked), nil
+}
+
+func (s *UserServiceImpl) GetAuditLog(ctx context.Context, userID int, limit int, offset int) ([]models.DomainAuditEntry, int, error) {
+	entries, total, err := s.auditRepository.GetAuditLog(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var domainEntries []models.DomainAuditEntry
+	for _, entry := range entries {
+		domainEntries = append(domainEntries, models.ToDomainAuditEntry(entry))
+	}
+
+	return domainEntries, total, nil
+}
+
+func checkPassword(policy PasswordPolicy, field string, password string, validationErr *se.ValidationError) {
+	for _, violation := range policy.Check(password) {
+		validationErr.Add(field, "password-"+violation.Slug, violation.Message)
+	}
+}
+
+// verifyPassword checks password against the stored hash of the user. A hash that can not be
+// verified, such as one in an unknown format, is logged and treated as a wrong password, since
+// the user can still get in by resetting it.
+func verifyPassword(hasher PasswordHasher, userID int, password string, hash string) (ok bool, rehash bool) {
+	ok, rehash, err := hasher.Verify(password, hash)
+	if err != nil {
+		log.Printf("failed to verify password of user %d: %v", userID, err)
+		return false, false
+	}
+	return ok, rehash
+}
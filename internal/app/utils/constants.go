@@ -1,9 +1,10 @@
 package utils
 
 const (
-	AuthorizationHeader            = "Authorization"
-	BearerPrefix                   = "Bearer"
-	ContextUserKey      contextKey = "UserKey"
+	AuthorizationHeader              = "Authorization"
+	BearerPrefix                     = "Bearer"
+	ContextUserKey        contextKey = "UserKey"
+	ContextPermissionsKey contextKey = "PermissionsKey"
 )
 
 type contextKey string
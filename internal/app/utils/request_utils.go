@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ClientIP returns the address of the peer. Forwarding headers are ignored because they are
+// set by the client unless a trusted proxy rewrites them.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RetryAfterSeconds returns a wait of d rounded up to whole seconds, as used by the Retry-After header.
+func RetryAfterSeconds(d time.Duration) string {
+	seconds := math.Ceil(d.Seconds())
+	return strconv.Itoa(max(int(seconds), 1))
+}
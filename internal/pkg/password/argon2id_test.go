@@ -0,0 +1,154 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testParams keep the tests fast; they are far below what production uses.
+var testParams = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func newTestArgon2idHasher(t *testing.T, params Argon2idParams) *Argon2idHasher {
+	t.Helper()
+
+	hasher, err := NewArgon2idHasher(params)
+	if err != nil {
+		t.Fatalf("NewArgon2idHasher(%+v) error = %v", params, err)
+	}
+	return hasher
+}
+
+func TestNewArgon2idHasher(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  Argon2idParams
+		wantErr bool
+	}{
+		{name: "valid", params: testParams},
+		{name: "too little memory per lane", params: Argon2idParams{Memory: 15, Iterations: 1, Parallelism: 2}, wantErr: true},
+		{name: "no iterations", params: Argon2idParams{Memory: 64, Iterations: 0, Parallelism: 1}, wantErr: true},
+		{name: "no parallelism", params: Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 0}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewArgon2idHasher(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewArgon2idHasher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestArgon2idHasherHash(t *testing.T) {
+	hasher := newTestArgon2idHasher(t, testParams)
+
+	hash, err := hasher.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("Hash() = %q, want the PHC format with the hasher params", hash)
+	}
+	if !hasher.Matches(hash) {
+		t.Errorf("Matches(%q) = false, want true", hash)
+	}
+
+	other, err := hasher.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if hash == other {
+		t.Error("two hashes of the same password are equal, want different salts")
+	}
+}
+
+func TestArgon2idHasherVerify(t *testing.T) {
+	hasher := newTestArgon2idHasher(t, testParams)
+
+	hash, err := hasher.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	// A hash made with other params must still verify, since params change over time.
+	otherParams := newTestArgon2idHasher(t, Argon2idParams{Memory: 128, Iterations: 2, Parallelism: 2})
+	otherHash, err := otherParams.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		want     bool
+		wantErr  bool
+	}{
+		{name: "right password", password: "correct horse battery staple", hash: hash, want: true},
+		{name: "wrong password", password: "correct horse battery stapler", hash: hash, want: false},
+		{name: "empty password", password: "", hash: hash, want: false},
+		{name: "hash with other params", password: "correct horse battery staple", hash: otherHash, want: true},
+		{name: "not an argon2id hash", password: "x", hash: "$2a$10$abcdefghijklmnopqrstuv", wantErr: true},
+		{name: "missing parts", password: "x", hash: "$argon2id$v=19$m=64,t=1,p=1$c2FsdA", wantErr: true},
+		{name: "unsupported version", password: "x", hash: "$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5", wantErr: true},
+		{name: "malformed params", password: "x", hash: "$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5", wantErr: true},
+		{name: "malformed salt", password: "x", hash: "$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5", wantErr: true},
+		{name: "empty key", password: "x", hash: "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hasher.Verify(tt.password, tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgon2idHasherVerifyUnknownFormat(t *testing.T) {
+	hasher := newTestArgon2idHasher(t, testParams)
+
+	_, err := hasher.Verify("x", "plain text")
+	if !errors.Is(err, ErrUnknownHash) {
+		t.Fatalf("Verify() error = %v, want ErrUnknownHash", err)
+	}
+}
+
+func TestArgon2idHasherNeedsRehash(t *testing.T) {
+	hasher := newTestArgon2idHasher(t, testParams)
+
+	current, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		params Argon2idParams
+		want   bool
+	}{
+		{name: "same params", params: testParams, want: false},
+		{name: "more memory", params: Argon2idParams{Memory: 128, Iterations: 1, Parallelism: 1}, want: true},
+		{name: "more iterations", params: Argon2idParams{Memory: 64, Iterations: 2, Parallelism: 1}, want: true},
+		{name: "more parallelism", params: Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 2}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newTestArgon2idHasher(t, tt.params).NeedsRehash(current); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if !hasher.NeedsRehash("$argon2id$broken") {
+		t.Error("NeedsRehash() = false for a malformed hash, want true")
+	}
+}
@@ -0,0 +1,54 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// Hasher is one version of the password hashing scheme. Every hasher writes a prefix that
+// identifies it, so that hashes of several versions can live side by side in the database.
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(password string, hash string) (bool, error)
+	// Matches reports whether hash was produced by this hasher.
+	Matches(hash string) bool
+	// NeedsRehash reports whether hash was produced with other parameters than the current ones.
+	NeedsRehash(hash string) bool
+}
+
+// Versioned hashes new passwords with the current hasher and still verifies hashes of the
+// older ones, which lets stored hashes be upgraded one sign-in at a time.
+type Versioned struct {
+	current Hasher
+	hashers []Hasher
+}
+
+func NewVersioned(current Hasher, older ...Hasher) *Versioned {
+	return &Versioned{
+		current: current,
+		hashers: append([]Hasher{current}, older...),
+	}
+}
+
+func (v *Versioned) Hash(password string) (string, error) {
+	return v.current.Hash(password)
+}
+
+// Verify checks password against hash. When the password is right, rehash tells whether the
+// hash is outdated and should be replaced with one from Hash.
+func (v *Versioned) Verify(password string, hash string) (ok bool, rehash bool, err error) {
+	for _, hasher := range v.hashers {
+		if !hasher.Matches(hash) {
+			continue
+		}
+
+		ok, err = hasher.Verify(password, hash)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		return true, hasher != v.current || hasher.NeedsRehash(hash), nil
+	}
+	return false, false, fmt.Errorf("failed to verify password: %w", ErrUnknownHash)
+}
@@ -0,0 +1,57 @@
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestVersionedVerify(t *testing.T) {
+	argon2id := newTestArgon2idHasher(t, testParams)
+	bcryptHasher := NewBcryptHasher(bcrypt.MinCost)
+	versioned := NewVersioned(argon2id, bcryptHasher)
+
+	currentHash, err := versioned.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	bcryptHash, err := bcryptHasher.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	outdatedHash, err := newTestArgon2idHasher(t, Argon2idParams{Memory: 128, Iterations: 1, Parallelism: 1}).Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		password   string
+		hash       string
+		wantOK     bool
+		wantRehash bool
+		wantErr    error
+	}{
+		{name: "current hash", password: "password", hash: currentHash, wantOK: true, wantRehash: false},
+		{name: "current hash, wrong password", password: "wrong", hash: currentHash},
+		{name: "bcrypt hash is upgraded", password: "password", hash: bcryptHash, wantOK: true, wantRehash: true},
+		{name: "bcrypt hash, wrong password", password: "wrong", hash: bcryptHash},
+		{name: "outdated argon2id params", password: "password", hash: outdatedHash, wantOK: true, wantRehash: true},
+		{name: "unknown format", password: "password", hash: "md5:5f4dcc3b5aa765d61d8327deb882cf99", wantErr: ErrUnknownHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash, err := versioned.Verify(tt.password, tt.hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK || rehash != tt.wantRehash {
+				t.Errorf("Verify() = (%v, %v), want (%v, %v)", ok, rehash, tt.wantOK, tt.wantRehash)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type CharacterClass string
+
+const (
+	ClassLower  CharacterClass = "lower"
+	ClassUpper  CharacterClass = "upper"
+	ClassDigit  CharacterClass = "digit"
+	ClassSymbol CharacterClass = "symbol"
+)
+
+func ParseCharacterClass(s string) (CharacterClass, error) {
+	switch class := CharacterClass(s); class {
+	case ClassLower, ClassUpper, ClassDigit, ClassSymbol:
+		return class, nil
+	default:
+		return "", fmt.Errorf("unknown character class %q", s)
+	}
+}
+
+func (c CharacterClass) description() string {
+	switch c {
+	case ClassLower:
+		return "a lowercase letter"
+	case ClassUpper:
+		return "an uppercase letter"
+	case ClassDigit:
+		return "a digit"
+	default:
+		return "a symbol"
+	}
+}
+
+func (c CharacterClass) contains(r rune) bool {
+	switch c {
+	case ClassLower:
+		return unicode.IsLower(r)
+	case ClassUpper:
+		return unicode.IsUpper(r)
+	case ClassDigit:
+		return unicode.IsDigit(r)
+	default:
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+	}
+}
+
+// Violation is one rule of the policy that a password breaks. Slug is stable and meant for
+// clients; Message is meant for people.
+type Violation struct {
+	Slug    string
+	Message string
+}
+
+// Policy decides which passwords are acceptable. Length is counted in characters, not bytes.
+type Policy struct {
+	minLength       int
+	maxLength       int
+	requiredClasses []CharacterClass
+	common          map[string]struct{}
+}
+
+// NewPolicy builds a policy. commonPasswordsPath names a file with one forbidden password per
+// line; it is optional.
+func NewPolicy(minLength int, maxLength int, requiredClasses []CharacterClass, commonPasswordsPath string) (*Policy, error) {
+	if minLength <= 0 || maxLength < minLength {
+		return nil, fmt.Errorf("invalid password length range %d-%d", minLength, maxLength)
+	}
+
+	common := make(map[string]struct{})
+	if commonPasswordsPath != "" {
+		var err error
+		if common, err = loadCommonPasswords(commonPasswordsPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Policy{
+		minLength:       minLength,
+		maxLength:       maxLength,
+		requiredClasses: requiredClasses,
+		common:          common,
+	}, nil
+}
+
+func loadCommonPasswords(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open common passwords: %w", err)
+	}
+	defer file.Close()
+
+	common := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		common[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read common passwords: %w", err)
+	}
+
+	return common, nil
+}
+
+// Check returns every rule that password breaks, or nil if it is acceptable.
+func (p *Policy) Check(password string) []Violation {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.minLength {
+		violations = append(violations, Violation{
+			Slug:    "too-short",
+			Message: fmt.Sprintf("must be at least %d characters long", p.minLength),
+		})
+	}
+	if length > p.maxLength {
+		violations = append(violations, Violation{
+			Slug:    "too-long",
+			Message: fmt.Sprintf("must be at most %d characters long", p.maxLength),
+		})
+	}
+
+	for _, class := range p.requiredClasses {
+		if !strings.ContainsFunc(password, class.contains) {
+			violations = append(violations, Violation{
+				Slug:    "missing-" + string(class),
+				Message: "must contain " + class.description(),
+			})
+		}
+	}
+
+	if _, ok := p.common[strings.ToLower(password)]; ok {
+		violations = append(violations, Violation{
+			Slug:    "too-common",
+			Message: "is too common",
+		})
+	}
+
+	return violations
+}
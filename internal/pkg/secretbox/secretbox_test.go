@@ -0,0 +1,124 @@
+package secretbox
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func newTestBox(t *testing.T, fill byte) *Box {
+	t.Helper()
+
+	box, err := New(bytes.Repeat([]byte{fill}, KeyLength))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return box
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     []byte
+		wantErr bool
+	}{
+		{name: "32 bytes", key: make([]byte, 32)},
+		{name: "empty", key: nil, wantErr: true},
+		{name: "16 bytes", key: make([]byte, 16), wantErr: true},
+		{name: "33 bytes", key: make([]byte, 33), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	box := newTestBox(t, 1)
+
+	tests := []struct {
+		name           string
+		plaintext      []byte
+		additionalData []byte
+	}{
+		{name: "secret with additional data", plaintext: []byte("totp secret"), additionalData: []byte("user:1")},
+		{name: "no additional data", plaintext: []byte("totp secret")},
+		{name: "empty secret", plaintext: []byte{}, additionalData: []byte("user:1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := box.Seal(tt.plaintext, tt.additionalData)
+			if err != nil {
+				t.Fatalf("Seal() error = %v", err)
+			}
+			if len(tt.plaintext) > 0 && bytes.Contains(sealed, tt.plaintext) {
+				t.Error("sealed secret contains the plaintext")
+			}
+
+			opened, err := box.Open(sealed, tt.additionalData)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			if !bytes.Equal(opened, tt.plaintext) {
+				t.Errorf("Open() = %q, want %q", opened, tt.plaintext)
+			}
+		})
+	}
+}
+
+func TestSealUsesFreshNonces(t *testing.T) {
+	box := newTestBox(t, 1)
+
+	first, err := box.Seal([]byte("secret"), nil)
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	second, err := box.Seal([]byte("secret"), nil)
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if bytes.Equal(first, second) {
+		t.Error("sealing the same secret twice gave the same result")
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	box := newTestBox(t, 1)
+
+	sealed, err := box.Seal([]byte("totp secret"), []byte("user:1"))
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 0xff
+
+	tests := []struct {
+		name           string
+		box            *Box
+		sealed         []byte
+		additionalData []byte
+	}{
+		{name: "other additional data", box: box, sealed: sealed, additionalData: []byte("user:2")},
+		{name: "missing additional data", box: box, sealed: sealed},
+		{name: "other key", box: newTestBox(t, 2), sealed: sealed, additionalData: []byte("user:1")},
+		{name: "tampered ciphertext", box: box, sealed: tampered, additionalData: []byte("user:1")},
+		{name: "shorter than a nonce", box: box, sealed: sealed[:4], additionalData: []byte("user:1")},
+		{name: "empty", box: box, sealed: nil, additionalData: []byte("user:1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.box.Open(tt.sealed, tt.additionalData)
+			if !errors.Is(err, ErrDecrypt) {
+				t.Fatalf("Open() error = %v, want ErrDecrypt", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 key of the RFC 4226 and RFC 6238 test vectors.
+var rfcSecret = []byte("12345678901234567890")
+
+// TestCodeRFC4226 checks the HOTP values of RFC 4226, Appendix D.
+func TestCodeRFC4226(t *testing.T) {
+	tests := []struct {
+		counter int64
+		want    string
+	}{
+		{counter: 0, want: "755224"},
+		{counter: 1, want: "287082"},
+		{counter: 2, want: "359152"},
+		{counter: 3, want: "969429"},
+		{counter: 4, want: "338314"},
+		{counter: 5, want: "254676"},
+		{counter: 6, want: "287922"},
+		{counter: 7, want: "162583"},
+		{counter: 8, want: "399871"},
+		{counter: 9, want: "520489"},
+	}
+
+	for _, tt := range tests {
+		if got := Code(rfcSecret, tt.counter); got != tt.want {
+			t.Errorf("Code(counter %d) = %q, want %q", tt.counter, got, tt.want)
+		}
+	}
+}
+
+// TestCodeRFC6238 checks the SHA1 values of RFC 6238, Appendix B. The RFC lists 8-digit codes;
+// 6-digit codes are their last six digits.
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		step int64
+		want string
+	}{
+		{unix: 59, step: 0x1, want: "287082"},
+		{unix: 1111111109, step: 0x23523EC, want: "081804"},
+		{unix: 1111111111, step: 0x23523ED, want: "050471"},
+		{unix: 1234567890, step: 0x273EF07, want: "005924"},
+		{unix: 2000000000, step: 0x3F940AA, want: "279037"},
+		{unix: 20000000000, step: 0x27BC86AA, want: "353130"},
+	}
+
+	for _, tt := range tests {
+		at := time.Unix(tt.unix, 0)
+		if got := Step(at); got != tt.step {
+			t.Errorf("Step(%d) = %#x, want %#x", tt.unix, got, tt.step)
+		}
+		if got := Code(rfcSecret, Step(at)); got != tt.want {
+			t.Errorf("Code(%d) = %q, want %q", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	current := Step(at)
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current step", code: "050471", skew: 1, wantStep: current, wantOK: true},
+		{name: "previous step within skew", code: Code(rfcSecret, current-1), skew: 1, wantStep: current - 1, wantOK: true},
+		{name: "next step within skew", code: Code(rfcSecret, current+1), skew: 1, wantStep: current + 1, wantOK: true},
+		{name: "previous step without skew", code: Code(rfcSecret, current-1), skew: 0},
+		{name: "two steps back", code: Code(rfcSecret, current-2), skew: 1},
+		{name: "wrong code", code: "000000", skew: 1},
+		{name: "too short", code: "05047", skew: 1},
+		{name: "too long", code: "0504710", skew: 1},
+		{name: "empty", code: "", skew: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, tt.code, at, tt.skew)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("Validate() = (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	if len(secret) != secretLength {
+		t.Errorf("len(secret) = %d, want %d", len(secret), secretLength)
+	}
+
+	other, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	if string(secret) == string(other) {
+		t.Error("two generated secrets are equal")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Book Shop", "user@example.com", rfcSecret)
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) error = %v", uri, err)
+	}
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" {
+		t.Errorf("URI() = %q, want an otpauth://totp/ URI", uri)
+	}
+	if parsed.Path != "/Book Shop:user@example.com" {
+		t.Errorf("label = %q, want %q", parsed.Path, "/Book Shop:user@example.com")
+	}
+
+	query := parsed.Query()
+	want := map[string]string{
+		"secret":    "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		"issuer":    "Book Shop",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	}
+	for key, value := range want {
+		if got := query.Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}